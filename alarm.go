@@ -0,0 +1,111 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+// Alarm starts playback on cast device at scheduled time with low volume,
+// then ramps the volume up gradually.
+type Alarm struct {
+	at         time.Time
+	urls       []*url.URL
+	text, lang string
+	from, to   float64
+	ramp       time.Duration
+}
+
+// NewAlarm creates an alarm which goes off at given time.
+// By default the volume ramps from 0.05 to 0.5 over 5 minutes.
+func NewAlarm(at time.Time) *Alarm {
+	return &Alarm{
+		at:   at,
+		from: 0.05,
+		to:   0.5,
+		ramp: 5 * time.Minute,
+	}
+}
+
+// Play sets media contents played by the alarm. Multiple urls are played as a playlist.
+func (a *Alarm) Play(urls ...*url.URL) *Alarm {
+	a.urls = urls
+	return a
+}
+
+// Speak sets text spoken by the alarm before media contents
+func (a *Alarm) Speak(text, lang string) *Alarm {
+	a.text = text
+	a.lang = lang
+	return a
+}
+
+// Ramp sets the volume range and the duration to ramp it up
+func (a *Alarm) Ramp(from, to float64, d time.Duration) *Alarm {
+	a.from = from
+	a.to = to
+	a.ramp = d
+	return a
+}
+
+// Run waits until the alarm time, then starts playback on cast device and ramps the volume up.
+// Speech is synthesized and urls are resolved when the alarm goes off, so that they don't expire while waiting.
+// It blocks until the ramp is done or ctx is cancelled.
+func (a *Alarm) Run(ctx context.Context, g *CastDevice) (err error) {
+	defer g.audit(ctx, "alarm", a.at.Format(time.RFC3339), &err)
+	if a.text == "" && len(a.urls) == 0 {
+		return errors.New("homecast: alarm has nothing to play")
+	}
+
+	select {
+	case <-g.clk().After(a.at.Sub(g.clk().Now())):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	items := make([]queueItem, 0, len(a.urls)+1)
+	if a.text != "" {
+		u, err := g.synthesize(ctx, a.text, a.lang)
+		if err != nil {
+			return err
+		}
+		items = append(items, newQueueItem(u, playOptions{}))
+	}
+	for _, u := range a.urls {
+		u, err := g.resolve(ctx, u, playOptions{})
+		if err != nil {
+			return err
+		}
+		items = append(items, newQueueItem(u, playOptions{}))
+	}
+
+	g.logf("[INFO] Alarm went off: %s", g.Name)
+	if err := g.setVolume(ctx, a.from); err != nil {
+		return err
+	}
+
+	s, err := g.launchMedia(ctx)
+	if err != nil {
+		return err
+	}
+	err = s.queueLoad(ctx, items, RepeatOff)
+	s.Close()
+	if err != nil {
+		return err
+	}
+
+	const step = 5 * time.Second
+	steps := int(a.ramp / step)
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-g.clk().After(step):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := g.setVolume(ctx, a.from+(a.to-a.from)*float64(i)/float64(steps)); err != nil {
+			return err
+		}
+	}
+	return g.setVolume(ctx, a.to)
+}
@@ -0,0 +1,132 @@
+package homecast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditTimeout bounds each push of AuditWebhook
+const auditTimeout = 5 * time.Second
+
+// AuditEntry records a command issued to cast device
+type AuditEntry struct {
+	Time time.Time `json:"time"`
+	// Caller is who issued the command, given by WithCaller
+	Caller  string `json:"caller,omitempty"`
+	Device  string `json:"device"`
+	Command string `json:"command"`
+	// Detail is the argument of the command, such as spoken text or media url
+	Detail string `json:"detail,omitempty"`
+	// Err is the error the command returned, empty on success
+	Err string `json:"error,omitempty"`
+}
+
+// Auditor receives every command issued to cast devices
+type Auditor interface {
+	Audit(e AuditEntry) error
+}
+
+var (
+	auditMu sync.RWMutex
+	auditor Auditor
+)
+
+// SetAuditor sets where commands issued to cast devices are recorded. nil disables auditing.
+func SetAuditor(a Auditor) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	auditor = a
+}
+
+type callerKey struct{}
+
+// WithCaller returns context whose commands are audited as issued by caller, such as name of an automation
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// audit records command to the auditor if set. It is deferred with pointer to the command's result.
+func (g *CastDevice) audit(ctx context.Context, command, detail string, err *error) {
+	auditMu.RLock()
+	a := auditor
+	auditMu.RUnlock()
+	if a == nil {
+		return
+	}
+
+	e := AuditEntry{Time: time.Now(), Device: g.Name, Command: command, Detail: detail}
+	e.Caller, _ = ctx.Value(callerKey{}).(string)
+	if *err != nil {
+		e.Err = (*err).Error()
+	}
+	if err := a.Audit(e); err != nil {
+		g.logf("[ERROR] Failed to audit: %v", err)
+	}
+}
+
+// AuditLog is Auditor appending entries to a file as JSON lines
+type AuditLog struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenAuditLog opens audit log file at path for appending, creating it if not exists
+func OpenAuditLog(path string) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{f: f}, nil
+}
+
+// Audit appends e as a line
+func (l *AuditLog) Audit(e AuditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.f.Write(append(b, '\n'))
+	return err
+}
+
+// Close closes the file
+func (l *AuditLog) Close() error {
+	return l.f.Close()
+}
+
+// AuditWebhook is Auditor pushing each entry to URL by POST as JSON
+type AuditWebhook struct {
+	URL string
+}
+
+// Audit posts e to the webhook
+func (w *AuditWebhook) Audit(e AuditEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("homecast: audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package homecast
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Briefing composes sections such as time, weather and calendar into one announcement
+// played as a queue of clips.
+type Briefing struct {
+	lang string
+	// sections build text at now, the time of cast device's clock when the briefing is played
+	sections []func(ctx context.Context, now time.Time) (string, error)
+}
+
+// NewBriefing creates a briefing spoken in lang
+func NewBriefing(lang string) *Briefing {
+	return &Briefing{lang: lang}
+}
+
+// Text adds a section of fixed text
+func (b *Briefing) Text(text string) *Briefing {
+	return b.Section(func(context.Context) (string, error) {
+		return text, nil
+	})
+}
+
+// Time adds a section telling current time
+func (b *Briefing) Time() *Briefing {
+	b.sections = append(b.sections, func(_ context.Context, now time.Time) (string, error) {
+		return fmt.Sprintf("It's %s.", now.Format("3:04 PM")), nil
+	})
+	return b
+}
+
+// Section adds a section whose text is built by f when the briefing is played,
+// such as weather forecast or today's calendar. Sections returning empty text are skipped.
+func (b *Briefing) Section(f func(context.Context) (string, error)) *Briefing {
+	b.sections = append(b.sections, func(ctx context.Context, _ time.Time) (string, error) {
+		return f(ctx)
+	})
+	return b
+}
+
+// Play builds each section and plays them in order on cast device.
+// A section which fails is logged and skipped, so that the rest is still played.
+func (b *Briefing) Play(ctx context.Context, g *CastDevice) error {
+	items := make([]queueItem, 0, len(b.sections))
+	now := g.clk().Now()
+	for _, section := range b.sections {
+		text, err := section(ctx, now)
+		if err != nil {
+			g.logf("[ERROR] Failed to build briefing section: %v", err)
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		u, err := g.synthesize(ctx, text, b.lang)
+		if err != nil {
+			return err
+		}
+		item := newQueueItem(u, playOptions{})
+		item.PreloadTime = queuePreloadTime
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	s, err := g.launchMedia(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	g.logf("[INFO] Load briefing: sections=%d", len(items))
+	return s.queueLoad(ctx, items, RepeatOff)
+}
+
+// Schedule plays the briefing on cast device every day at hour:minute. Call returned func to cancel.
+func (b *Briefing) Schedule(s *Scheduler, hour, minute int, g *CastDevice) (cancel func()) {
+	return s.Daily(hour, minute, func(ctx context.Context) {
+		if err := b.Play(ctx, g); err != nil {
+			g.logf("[ERROR] Failed to play briefing: %v", err)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package homecast
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SpeakError is failure of speaking on one device of a broadcast
+type SpeakError struct {
+	Device Device
+	Err    error
+}
+
+func (e *SpeakError) Error() string {
+	if g, ok := e.Device.(*CastDevice); ok {
+		return fmt.Sprintf("homecast: failed to speak on %s: %v", g.Name, e.Err)
+	}
+	return fmt.Sprintf("homecast: failed to speak: %v", e.Err)
+}
+
+func (e *SpeakError) Unwrap() error {
+	return e.Err
+}
+
+// BroadcastError holds failures of devices in a broadcast. Other devices have spoken.
+type BroadcastError struct {
+	Errors []*SpeakError
+}
+
+func (e *BroadcastError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns failure of each device, so that errors.Is and errors.As look into them
+func (e *BroadcastError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Broadcast speaks on many devices at once, like broadcast of the Google Home app
+type Broadcast struct {
+	Devices []Device
+	// Pool limits how many devices are spoken on concurrently. DefaultPool is used when nil.
+	Pool *Pool
+}
+
+// Speak speaks text on all devices in parallel. It returns *BroadcastError if some of them failed.
+func (b *Broadcast) Speak(ctx context.Context, text, lang string, opts ...PlayOption) error {
+	pool := b.Pool
+	if pool == nil {
+		pool = DefaultPool
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []*SpeakError
+	for _, device := range b.Devices {
+		device := device
+		wg.Add(1)
+		pool.Go(func() {
+			defer wg.Done()
+			if err := device.Speak(ctx, text, lang, opts...); err != nil {
+				mu.Lock()
+				errs = append(errs, &SpeakError{Device: device, Err: err})
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return &BroadcastError{Errors: errs}
+	}
+	return nil
+}
+
+// SpeakAll speaks text on all devices in parallel. It returns *BroadcastError if some of them failed.
+func SpeakAll(ctx context.Context, devices []*CastDevice, text, lang string, opts ...PlayOption) error {
+	b := &Broadcast{Devices: make([]Device, len(devices))}
+	for i, device := range devices {
+		b.Devices[i] = device
+	}
+	return b.Speak(ctx, text, lang, opts...)
+}
@@ -0,0 +1,127 @@
+package homecast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ErrMessageNotFound is returned when a catalog has no message for the key in any acceptable language
+var ErrMessageNotFound = errors.New("homecast: message not found")
+
+// Catalog holds announcement templates by language, so that one message key is spoken
+// in the language of each device
+type Catalog struct {
+	// Fallback is language used when a device's language has no message. Default is "en".
+	Fallback string
+
+	messages map[string]map[string]*template.Template
+}
+
+// LoadCatalog loads message catalog from files named "<lang>.json" in dir, such as "en.json" and "ja.json".
+// Each file is an object of message key to text/template text, such as {"door_open": "{{.Door}} is open"}.
+func LoadCatalog(dir string) (*Catalog, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var texts map[string]string
+		if err := json.Unmarshal(b, &texts); err != nil {
+			return nil, fmt.Errorf("homecast: invalid catalog %s: %w", file, err)
+		}
+		lang := strings.TrimSuffix(filepath.Base(file), ".json")
+		for key, text := range texts {
+			if err := c.Add(lang, key, text); err != nil {
+				return nil, fmt.Errorf("homecast: invalid catalog %s: %w", file, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Add adds message template of key in lang
+func (c *Catalog) Add(lang, key, text string) error {
+	t, err := template.New(key).Parse(text)
+	if err != nil {
+		return err
+	}
+	if c.messages == nil {
+		c.messages = map[string]map[string]*template.Template{}
+	}
+	lang = strings.ToLower(lang)
+	if c.messages[lang] == nil {
+		c.messages[lang] = map[string]*template.Template{}
+	}
+	c.messages[lang][key] = t
+	return nil
+}
+
+// Render renders message of key in lang with data. It falls back to the base language,
+// such as "en" for "en-US", and then to Fallback.
+func (c *Catalog) Render(key, lang string, data interface{}) (string, error) {
+	t, ok := c.lookup(key, lang)
+	if !ok {
+		return "", fmt.Errorf("%w: %s (%s)", ErrMessageNotFound, key, lang)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Speak renders message of key in the language of cast device and speaks it
+func (c *Catalog) Speak(ctx context.Context, g *CastDevice, key string, data interface{}, opts ...PlayOption) error {
+	lang := g.Lang()
+	if lang == "" {
+		lang = c.fallback()
+	}
+	text, err := c.Render(key, lang, data)
+	if err != nil {
+		return err
+	}
+	return g.Speak(ctx, text, lang, opts...)
+}
+
+func (c *Catalog) lookup(key, lang string) (*template.Template, bool) {
+	lang = strings.ToLower(lang)
+	candidates := []string{lang}
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		candidates = append(candidates, lang[:i])
+	}
+	candidates = append(candidates, strings.ToLower(c.fallback()))
+	for _, l := range candidates {
+		if t, ok := c.messages[l][key]; ok {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (c *Catalog) fallback() string {
+	if c.Fallback == "" {
+		return "en"
+	}
+	return c.Fallback
+}
+
+// SetLang sets language announcements from Catalog are spoken in on cast device
+func (g *CastDevice) SetLang(lang string) {
+	g.configure(func(s *deviceSettings) { s.lang = lang })
+}
+
+// Lang returns language set by SetLang
+func (g *CastDevice) Lang() string {
+	return g.currentSettings().lang
+}
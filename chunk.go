@@ -0,0 +1,243 @@
+package homecast
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// translateMaxLength is the longest text translate_tts speaks without truncation
+const translateMaxLength = 200
+
+const (
+	sentenceTerminators = "。！？!?．｡"
+	clauseSeparators    = "、，,;；:："
+	closingPunctuation  = "」』）)\"'”’"
+)
+
+// SplitSentences splits text into chunks of at most max characters on sentence boundaries,
+// including Japanese punctuation. Sentences longer than max are split on clause boundaries,
+// then on spaces, and at max characters as the last resort.
+// If max is not positive, text is returned as a single chunk.
+func SplitSentences(text string, max int) []string {
+	if max <= 0 {
+		if s := strings.TrimSpace(text); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+	var chunks []string
+	var current string
+	for _, sentence := range sentences(text) {
+		for _, part := range splitLong(sentence, max) {
+			if current == "" {
+				current = part
+			} else if joined := join(current, part); utf8.RuneCountInString(joined) <= max {
+				current = joined
+			} else {
+				chunks = append(chunks, current)
+				current = part
+			}
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// sentences splits text after sentence terminators
+func sentences(text string) []string {
+	var list []string
+	runes := []rune(text)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		end := r == '\n' || strings.ContainsRune(sentenceTerminators, r) || r == '.' && endsSentence(runes[i+1:])
+		if !end {
+			continue
+		}
+		for i+1 < len(runes) && strings.ContainsRune(closingPunctuation, runes[i+1]) {
+			i++
+		}
+		if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+			list = append(list, s)
+		}
+		start = i + 1
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		list = append(list, s)
+	}
+	return list
+}
+
+// endsSentence reports whether a period followed by rest ends a sentence, rather than being
+// a decimal point or an abbreviation inside a word: rest starts with space after closing punctuation
+func endsSentence(rest []rune) bool {
+	for len(rest) > 0 && strings.ContainsRune(closingPunctuation, rest[0]) {
+		rest = rest[1:]
+	}
+	return len(rest) == 0 || unicode.IsSpace(rest[0])
+}
+
+// splitLong splits a sentence longer than max into parts
+func splitLong(sentence string, max int) []string {
+	runes := []rune(sentence)
+	var parts []string
+	for len(runes) > max {
+		cut := lastIndexFunc(runes[:max], func(r rune) bool { return strings.ContainsRune(clauseSeparators, r) })
+		if cut < 0 {
+			cut = lastIndexFunc(runes[:max], unicode.IsSpace)
+		}
+		if cut < 0 {
+			cut = max - 1
+		}
+		if s := strings.TrimSpace(string(runes[:cut+1])); s != "" {
+			parts = append(parts, s)
+		}
+		runes = runes[cut+1:]
+	}
+	if s := strings.TrimSpace(string(runes)); s != "" {
+		parts = append(parts, s)
+	}
+	return parts
+}
+
+func lastIndexFunc(runes []rune, f func(rune) bool) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if f(runes[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// join concatenates sentences, without space after CJK punctuation
+func join(a, b string) string {
+	if last, _ := utf8.DecodeLastRuneInString(a); last >= 0x3000 {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// SpeakLong speaks text longer than TTS can handle at once, split on sentence boundaries.
+// Chunks are played gaplessly when pause is zero, otherwise pause is inserted between them.
+// As with Speak, it is recorded in history and play options such as WithVolume apply.
+func (g *CastDevice) SpeakLong(ctx context.Context, text, lang string, pause time.Duration, opts ...PlayOption) (err error) {
+	defer g.audit(ctx, "speak_long", text, &err)
+	max := translateMaxLength
+	// Unknown provider fails in speak, so that it is recorded in history
+	if p, err := g.ttsFor(newPlayOptions(opts)); err == nil && maxTextLength(p) > 0 {
+		max = maxTextLength(p)
+	}
+	return g.speak(ctx, chunkSegments(SplitSentences(text, max), lang), "", pause, opts)
+}
+
+// chunkSegments returns chunks as segments spoken in lang
+func chunkSegments(chunks []string, lang string) []Segment {
+	segments := make([]Segment, len(chunks))
+	for i, chunk := range chunks {
+		segments[i] = Segment{Text: chunk, Lang: lang}
+	}
+	return segments
+}
+
+func (g *CastDevice) speakChunks(ctx context.Context, chunks []Segment, pause time.Duration, o playOptions) error {
+	items := make([]queueItem, 0, len(chunks))
+	contentIDs := make([]string, 0, len(chunks))
+	p, err := g.ttsFor(o)
+	if err != nil {
+		o.complete(o.session(g, "", err))
+		return err
+	}
+	var text strings.Builder
+	for _, chunk := range chunks {
+		text.WriteString(chunk.Text)
+	}
+	voice := o.voiceFor(text.String())
+	for _, chunk := range chunks {
+		u, err := g.synthesizeVoice(ctx, p, chunk.Text, "", chunk.Lang, voice)
+		if err == nil {
+			u, err = o.process(ctx, g, u)
+		}
+		if err != nil {
+			o.complete(o.session(g, "", err))
+			return err
+		}
+		item := newQueueItem(u, o)
+		item.PreloadTime = queuePreloadTime
+		if o.caption {
+			// Caption follows the chunk being spoken
+			item.Media.Metadata = &mediaMetadata{Title: chunk.Text}
+		} else if o.title != "" {
+			item.Media.Metadata = &mediaMetadata{Title: o.title}
+		}
+		items = append(items, item)
+		contentIDs = append(contentIDs, item.Media.ContentId)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	s, err := g.launchMedia(ctx)
+	if err != nil {
+		o.complete(o.session(g, contentIDs[0], err))
+		return err
+	}
+
+	if pause > 0 {
+		defer s.Close()
+		err := g.playChunks(ctx, s, items, pause)
+		o.complete(o.session(g, contentIDs[0], err))
+		return err
+	}
+
+	g.logf("[INFO] Load chunks: count=%d", len(items))
+	if err := s.queueLoad(ctx, items, RepeatOff); err != nil {
+		s.Close()
+		o.complete(o.session(g, contentIDs[0], err))
+		return err
+	}
+	publish(SessionStarted{o.session(g, contentIDs[0], nil)})
+
+	if o.wait {
+		defer s.Close()
+		err := s.wait(ctx, contentIDs...)
+		o.complete(o.session(g, contentIDs[0], err))
+		return err
+	}
+	if o.onComplete == nil && len(o.hooks) == 0 && !hasSubscribers() {
+		s.Close()
+		return nil
+	}
+	// Keep watching after return until the device is closed; ctx may end with the caller
+	go func() {
+		defer s.Close()
+		err := s.wait(g.ctx, contentIDs...)
+		o.complete(o.session(g, contentIDs[0], err))
+	}()
+	return nil
+}
+
+// playChunks plays items one by one with pause between them
+func (g *CastDevice) playChunks(ctx context.Context, s *mediaSession, items []queueItem, pause time.Duration) error {
+	for i, item := range items {
+		if i > 0 {
+			select {
+			case <-g.clk().After(pause):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		g.logf("[INFO] Load chunk: %d/%d", i+1, len(items))
+		if err := s.load(ctx, item.Media); err != nil {
+			return err
+		}
+		if err := s.wait(ctx, item.Media.ContentId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package homecast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"english", "Hello. How are you? Fine!", []string{"Hello.", "How are you?", "Fine!"}},
+		{"decimal", "Pi is 3.14 or so.", []string{"Pi is 3.14 or so."}},
+		{"newline", "first\nsecond", []string{"first", "second"}},
+		{"japanese", "おはよう。今日は晴れです！", []string{"おはよう。", "今日は晴れです！"}},
+		{"closing quote", "「こんにちは。」と言った。", []string{"「こんにちは。」", "と言った。"}},
+		{"closing quote english", `He said "stop." Then left.`, []string{`He said "stop."`, "Then left."}},
+		{"no terminator", "no terminator", []string{"no terminator"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sentences(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want []string
+	}{
+		{"fits", "Hello. World.", 200, []string{"Hello. World."}},
+		{"joined up to max", "One. Two. Three.", 9, []string{"One. Two.", "Three."}},
+		{"japanese without space", "おはよう。こんにちは。", 11, []string{"おはよう。こんにちは。"}},
+		{"clause", "first part, second part.", 15, []string{"first part,", "second part."}},
+		{"space", "aaa bbb ccc", 5, []string{"aaa", "bbb", "ccc"}},
+		{"japanese clause", "今日は、晴れです。", 5, []string{"今日は、", "晴れです。"}},
+		{"hard cut", "abcdefg", 3, []string{"abc", "def", "g"}},
+		{"separator at start", ",abcd", 2, []string{",", "ab", "cd"}},
+		{"max 1", "ab c", 1, []string{"a", "b", "c"}},
+		{"max 0", " Hello. World. ", 0, []string{"Hello. World."}},
+		{"negative max", "Hello.", -1, []string{"Hello."}},
+		{"empty max 0", "  ", 0, nil},
+		{"japanese over max", "おはよう。こんにちは。", 10, []string{"おはよう。", "こんにちは。"}},
+		{"empty", "", 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitSentences(tt.text, tt.max); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitSentences(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package homecast
+
+import "time"
+
+// Clock provides current time and timers to schedules, fades, retries and polling of cast device.
+// Tests can replace it to fast-forward time instead of sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// Ticker is implemented by clocks which tick periodically, so that polling doesn't allocate a timer every time
+type Ticker interface {
+	// Tick returns channel ticking every d, and func to stop it
+	Tick(d time.Duration) (<-chan time.Time, func())
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (realClock) Tick(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// tick returns channel ticking every d by c, and func to stop it.
+// Clocks not implementing Ticker tick by After.
+func tick(c Clock, d time.Duration) (<-chan time.Time, func()) {
+	if t, ok := c.(Ticker); ok {
+		return t.Tick(d)
+	}
+	ch := make(chan time.Time)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case t := <-c.After(d):
+				select {
+				case ch <- t:
+				case <-done:
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch, func() { close(done) }
+}
+
+// SetClock replaces clock used by cast device. Default is the system clock.
+func (g *CastDevice) SetClock(c Clock) {
+	g.configure(func(s *deviceSettings) { s.clock = c })
+}
+
+func (g *CastDevice) clk() Clock {
+	if c := g.currentSettings().clock; c != nil {
+		return c
+	}
+	return realClock{}
+}
@@ -0,0 +1,95 @@
+package homecast
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const cloudTTSEndpoint = "https://texttospeech.googleapis.com/v1/text:synthesize"
+
+// GoogleCloudTTS is TTSProvider using Google Cloud Text-to-Speech.
+// Synthesized audio is served to cast devices by MediaServer.
+type GoogleCloudTTS struct {
+	// APIKey is API key of Google Cloud project with Text-to-Speech API enabled
+	APIKey string
+	// Voice is voice name such as "en-US-Wavenet-D". Default voice of the language is used when empty.
+	Voice string
+	// Server serves synthesized audio. DefaultMediaServer is used when nil.
+	Server *MediaServer
+}
+
+// MaxTextLength returns input limit of Cloud TTS, 5000 bytes, budgeted for 3-byte characters
+func (t *GoogleCloudTTS) MaxTextLength() int {
+	return 5000 / 3
+}
+
+// Synthesize synthesizes text and returns url of the audio served locally
+func (t *GoogleCloudTTS) Synthesize(ctx context.Context, text, lang string) (*url.URL, error) {
+	return t.SynthesizeRate(ctx, text, lang, 1)
+}
+
+// SynthesizeRate synthesizes text spoken at rate times the normal speed
+func (t *GoogleCloudTTS) SynthesizeRate(ctx context.Context, text, lang string, rate float64) (*url.URL, error) {
+	return t.synthesize(ctx, map[string]string{"text": text}, lang, Voice{Rate: rate})
+}
+
+// SynthesizeSSML synthesizes SSML document with voice. Name of voice replaces Voice of t.
+func (t *GoogleCloudTTS) SynthesizeSSML(ctx context.Context, ssml, lang string, voice Voice) (*url.URL, error) {
+	return t.synthesize(ctx, map[string]string{"ssml": ssml}, lang, voice)
+}
+
+func (t *GoogleCloudTTS) synthesize(ctx context.Context, input map[string]string, lang string, voice Voice) (*url.URL, error) {
+	name := voice.Name
+	if name == "" {
+		name = t.Voice
+	}
+	audioConfig := map[string]interface{}{"audioEncoding": "MP3", "pitch": voice.Pitch}
+	if voice.Rate != 0 {
+		audioConfig["speakingRate"] = voice.Rate
+	}
+	body := map[string]interface{}{
+		"input":       input,
+		"voice":       map[string]string{"languageCode": lang, "name": name},
+		"audioConfig": audioConfig,
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cloudTTSEndpoint+"?key="+url.QueryEscape(t.APIKey), bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("homecast: cloud tts returned %s", resp.Status)
+	}
+
+	var result struct {
+		AudioContent string `json:"audioContent"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	audio, err := base64.StdEncoding.DecodeString(result.AudioContent)
+	if err != nil {
+		return nil, err
+	}
+
+	server := t.Server
+	if server == nil {
+		server = DefaultMediaServer
+	}
+	return server.ServeBytes(audio, "audio/mpeg")
+}
@@ -0,0 +1,105 @@
+package homecast
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Coalescer combines messages arriving within a short window into one announcement,
+// instead of speaking them back-to-back. Use one Coalescer per kind of message to coalesce.
+type Coalescer struct {
+	// Format builds announcement text from messages. Default is "3 new alerts: a. b. c."
+	Format func(messages []string) string
+	// Logger logs failures of speaking. Default logs nothing.
+	Logger Logger
+	// Clock is used to wait for the window. Default is the system clock.
+	Clock Clock
+
+	device Device
+	lang   string
+	window time.Duration
+
+	mu      sync.Mutex
+	pending []string
+	// stop is closed to cancel waiting for the window of pending messages
+	stop chan struct{}
+}
+
+// NewCoalescer creates a coalescer which speaks on device in lang, window after the first message arrives
+func NewCoalescer(device Device, lang string, window time.Duration) *Coalescer {
+	return &Coalescer{device: device, lang: lang, window: window}
+}
+
+// Add queues text to be spoken with other messages arriving within the window
+func (c *Coalescer) Add(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending, text)
+	if c.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	c.stop = stop
+	window := c.clk().After(c.window)
+	go func() {
+		select {
+		case <-window:
+			c.flush(stop)
+		case <-stop:
+		}
+	}()
+}
+
+// Flush speaks queued messages immediately
+func (c *Coalescer) Flush() {
+	c.flush(nil)
+}
+
+// flush speaks queued messages. When called as the window of stop elapsed,
+// it does nothing if the messages were flushed meanwhile.
+func (c *Coalescer) flush(stop chan struct{}) {
+	c.mu.Lock()
+	if stop != nil && stop != c.stop {
+		c.mu.Unlock()
+		return
+	}
+	messages := c.pending
+	c.pending = nil
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+	c.mu.Unlock()
+
+	if len(messages) == 0 {
+		return
+	}
+	format := c.Format
+	if format == nil {
+		format = formatAlerts
+	}
+	if err := c.device.Speak(context.Background(), format(messages), c.lang); err != nil {
+		logf(c.Logger, "[ERROR] Failed to speak coalesced messages: %v", err)
+	}
+}
+
+func (c *Coalescer) clk() Clock {
+	if c.Clock == nil {
+		return realClock{}
+	}
+	return c.Clock
+}
+
+func formatAlerts(messages []string) string {
+	if len(messages) == 1 {
+		return messages[0]
+	}
+	parts := make([]string, len(messages))
+	for i, m := range messages {
+		parts[i] = strings.TrimRight(m, ".") + "."
+	}
+	return fmt.Sprintf("%d new alerts: %s", len(messages), strings.Join(parts, " "))
+}
@@ -0,0 +1,136 @@
+package homecast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeviceConfig is settings of a cast device, identified by its friendly name
+type DeviceConfig struct {
+	Name string `json:"name"`
+	// Addr is address of the device when exported, such as "192.168.1.20:8009"
+	Addr           string         `json:"addr,omitempty"`
+	Lang           string         `json:"lang,omitempty"`
+	MaxVolume      float64        `json:"max_volume,omitempty"`
+	VolumePolicy   VolumePolicy   `json:"volume_policy,omitempty"`
+	VolumeSchedule VolumeSchedule `json:"volume_schedule,omitempty"`
+	NoSplit        bool           `json:"no_split,omitempty"`
+	AutoReconnect  bool           `json:"auto_reconnect,omitempty"`
+	Takeover       TakeoverPolicy `json:"takeover,omitempty"`
+	TakeoverDelay  time.Duration  `json:"takeover_delay,omitempty"`
+}
+
+// Config is settings of devices and aliases, to move a setup to another host
+type Config struct {
+	Devices []DeviceConfig      `json:"devices"`
+	Aliases map[string][]string `json:"aliases,omitempty"`
+	// Default is friendly name or address of the device DefaultDevice resolves to,
+	// when the config file is given by HOMECAST_CONFIG
+	Default string `json:"default,omitempty"`
+}
+
+// ExportConfig writes settings of devices and registered aliases to w as JSON
+func ExportConfig(w io.Writer, devices []*CastDevice) error {
+	cfg := Config{Devices: make([]DeviceConfig, 0, len(devices)), Aliases: aliases()}
+	for _, g := range devices {
+		cfg.Devices = append(cfg.Devices, g.config())
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+// ImportConfig reads settings written by ExportConfig from r, applies them to devices
+// with the same friendly names, ignoring case, and registers the aliases.
+// Settings of devices not given are still applied to the others, and ErrDeviceNotFound is returned.
+func ImportConfig(r io.Reader, devices []*CastDevice) error {
+	var cfg Config
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return fmt.Errorf("homecast: invalid config: %w", err)
+	}
+	for alias, names := range cfg.Aliases {
+		RegisterAlias(alias, names...)
+	}
+
+	var missing []string
+	for _, c := range cfg.Devices {
+		found := false
+		for _, g := range devices {
+			if strings.EqualFold(g.FriendlyName(), c.Name) {
+				g.applyConfig(c)
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, c.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrDeviceNotFound, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// readConfig reads config file at path written by ExportConfig
+func readConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var cfg Config
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("homecast: invalid config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// config returns settings of cast device
+func (g *CastDevice) config() DeviceConfig {
+	s := g.currentSettings()
+	c := DeviceConfig{
+		Name:           g.FriendlyName(),
+		Lang:           s.lang,
+		MaxVolume:      s.maxVolume,
+		VolumePolicy:   s.volPolicy,
+		VolumeSchedule: s.volSchedule,
+		NoSplit:        s.noSplit,
+		AutoReconnect:  s.autoReconnect,
+		Takeover:       s.takeover,
+		TakeoverDelay:  s.takeoverDelay,
+	}
+	if addr := entryAddr(g.ServiceEntry); addr != nil {
+		c.Addr = net.JoinHostPort(addr.String(), strconv.Itoa(g.Port))
+	}
+	return c
+}
+
+// applyConfig applies settings to cast device at once
+func (g *CastDevice) applyConfig(c DeviceConfig) {
+	g.configure(func(s *deviceSettings) {
+		s.lang = c.Lang
+		s.maxVolume = c.MaxVolume
+		s.volPolicy = c.VolumePolicy
+		s.volSchedule = c.VolumeSchedule
+		s.noSplit = c.NoSplit
+		s.autoReconnect = c.AutoReconnect
+		s.takeover, s.takeoverDelay = c.Takeover, c.TakeoverDelay
+	})
+}
+
+// aliases returns a copy of registered aliases
+func aliases() map[string][]string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	m := make(map[string][]string, len(aliasRegistry))
+	for alias, names := range aliasRegistry {
+		m[alias] = append([]string(nil), names...)
+	}
+	return m
+}
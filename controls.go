@@ -0,0 +1,85 @@
+package homecast
+
+import (
+	"context"
+	"time"
+
+	castnet "github.com/barnybug/go-cast/net"
+)
+
+type seekCommand struct {
+	castnet.PayloadHeaders
+	MediaSessionID int     `json:"mediaSessionId"`
+	CurrentTime    float64 `json:"currentTime"`
+}
+
+// Pause pauses media playing on cast device
+func (g *CastDevice) Pause(ctx context.Context) (err error) {
+	defer g.audit(ctx, "pause", "", &err)
+	s, err := g.attachMedia(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	msg, err := s.media.Pause(ctx)
+	if err != nil {
+		return err
+	}
+	return checkResponse(msg)
+}
+
+// Resume resumes paused media on cast device
+func (g *CastDevice) Resume(ctx context.Context) (err error) {
+	defer g.audit(ctx, "resume", "", &err)
+	s, err := g.attachMedia(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	msg, err := s.media.Play(ctx)
+	if err != nil {
+		return err
+	}
+	return checkResponse(msg)
+}
+
+// Stop stops media playing on cast device. It is not an error that nothing is playing.
+func (g *CastDevice) Stop(ctx context.Context) (err error) {
+	defer g.audit(ctx, "stop", "", &err)
+	s, err := g.attachMedia(ctx)
+	if err == ErrNoMediaSession {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	msg, err := s.media.Stop(ctx)
+	if err != nil {
+		return err
+	}
+	return checkResponse(msg)
+}
+
+// Seek moves playback of current media to position
+func (g *CastDevice) Seek(ctx context.Context, position time.Duration) (err error) {
+	defer g.audit(ctx, "seek", position.String(), &err)
+	s, err := g.attachMedia(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	msg, err := s.channel.Request(ctx, &seekCommand{
+		PayloadHeaders: castnet.PayloadHeaders{Type: "SEEK"},
+		MediaSessionID: s.media.MediaSessionID,
+		CurrentTime:    position.Seconds(),
+	})
+	if err != nil {
+		return err
+	}
+	return checkResponse(msg)
+}
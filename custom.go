@@ -0,0 +1,103 @@
+package homecast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/barnybug/go-cast"
+	"github.com/barnybug/go-cast/controllers"
+	castnet "github.com/barnybug/go-cast/net"
+)
+
+// customRequestTimeout bounds Request when ctx has no deadline
+const customRequestTimeout = 10 * time.Second
+
+// customPayload is a JSON object sent on custom namespace.
+// requestId is added by the channel to correlate the reply.
+type customPayload struct {
+	castnet.PayloadHeaders
+	fields map[string]interface{}
+}
+
+func (p *customPayload) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]interface{}, len(p.fields)+1)
+	for k, v := range p.fields {
+		fields[k] = v
+	}
+	if p.RequestId != nil {
+		fields["requestId"] = *p.RequestId
+	}
+	return json.Marshal(fields)
+}
+
+// Request sends req as JSON object on namespace of the receiver app, and decodes its reply into resp.
+// The app is launched unless it is already running. The reply is correlated by requestId,
+// so the receiver app must echo requestId of the request.
+func (g *CastDevice) Request(ctx context.Context, appID, namespace string, req, resp interface{}) (err error) {
+	defer g.audit(ctx, "request", appID+" "+namespace, &err)
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, customRequestTimeout)
+		defer cancel()
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	payload := &customPayload{}
+	if err := json.Unmarshal(b, &payload.fields); err != nil {
+		return fmt.Errorf("homecast: request must be a JSON object: %v", err)
+	}
+
+	conn, transportID, err := g.connectApp(ctx, appID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	channel := conn.NewChannel(cast.DefaultSender, transportID, namespace)
+	msg, err := channel.Request(ctx, payload)
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(msg.GetPayloadUtf8()), resp)
+}
+
+// connectApp launches receiver app if it is not running, and connects to it
+func (g *CastDevice) connectApp(ctx context.Context, appID string) (*castnet.Connection, string, error) {
+	var status *controllers.ReceiverStatus
+	err := g.withReconnect(ctx, func() (err error) {
+		status, err = g.castClient().Receiver().GetStatus(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	app := status.GetSessionByAppId(appID)
+	if app == nil {
+		if status, err = g.castClient().Receiver().LaunchApp(ctx, appID); err != nil {
+			return nil, "", err
+		}
+		app = status.GetSessionByAppId(appID)
+	}
+	if app == nil || app.TransportId == nil {
+		return nil, "", ErrAppNotLaunched
+	}
+
+	conn := castnet.NewConnection()
+	if err := conn.Connect(ctx, entryAddr(g.ServiceEntry), g.Port); err != nil {
+		return nil, "", err
+	}
+	cc := controllers.NewConnectionController(conn, g.castClient().Events, cast.DefaultSender, *app.TransportId)
+	if err := cc.Start(ctx); err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	return conn, *app.TransportId, nil
+}
@@ -0,0 +1,117 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/micro/mdns"
+)
+
+const (
+	// defaultDeviceEnv names the device DefaultDevice resolves to
+	defaultDeviceEnv = "HOMECAST_DEVICE"
+	// defaultConfigEnv is path of Config file whose Default names the device DefaultDevice resolves to
+	defaultConfigEnv = "HOMECAST_CONFIG"
+	defaultCastPort  = 8009
+)
+
+var (
+	// ErrDeviceNotFound is returned when no device matches the request
+	ErrDeviceNotFound = errors.New("homecast: device not found")
+	// ErrAmbiguousDevice is returned when a single device is requested but several are found
+	ErrAmbiguousDevice = errors.New("homecast: multiple devices found")
+)
+
+// DefaultDevice returns the device simple scripts should speak on.
+// If HOMECAST_DEVICE is set, it is the friendly name of the device, or its address as "host" or "host:port".
+// Otherwise if HOMECAST_CONFIG is set, it is path of Config file whose Default names the device the same way.
+// Otherwise the only device on the network is returned, without connecting to any when there are several.
+func DefaultDevice(ctx context.Context) (*CastDevice, error) {
+	target := os.Getenv(defaultDeviceEnv)
+	if path := os.Getenv(defaultConfigEnv); target == "" && path != "" {
+		cfg, err := readConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		target = cfg.Default
+	}
+	if device, ok, err := connectAddr(ctx, target); ok {
+		return device, err
+	}
+
+	if target != "" {
+		return LookupDevice(ctx, target)
+	}
+	return lookupOnly(ctx, newDiscoverOptions(nil), "", func(*mdns.ServiceEntry) bool { return true })
+}
+
+// LookupDevice discovers the device whose friendly name is name, ignoring case, and connects only to it.
+// If it is not found and discovery failed, the error matches both ErrDeviceNotFound and *LookupError.
+func LookupDevice(ctx context.Context, name string, opts ...DiscoverOption) (*CastDevice, error) {
+	return lookupOnly(ctx, newDiscoverOptions(opts), name, func(entry *mdns.ServiceEntry) bool {
+		return strings.EqualFold(ParseCastTXT(entry.InfoFields).FriendlyName(), name)
+	})
+}
+
+// lookupOnly discovers devices and connects to the only one matching match, described by name in errors.
+// Several entries of the same device, such as from several discovery backends, count as one.
+func lookupOnly(ctx context.Context, o *DiscoverOptions, name string, match func(*mdns.ServiceEntry) bool) (*CastDevice, error) {
+	var found *mdns.ServiceEntry
+	entries, errs := scan(ctx, o)
+	for _, entry := range entries {
+		if !match(entry) {
+			continue
+		}
+		if found != nil && deviceKey(found) != deviceKey(entry) {
+			return nil, describeDevice(ErrAmbiguousDevice, name)
+		}
+		found = entry
+	}
+	if found == nil && len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", describeDevice(ErrDeviceNotFound, name), &LookupError{Discovery: errs})
+	}
+	if found == nil {
+		return nil, describeDevice(ErrDeviceNotFound, name)
+	}
+
+	logf(o.Logger, "[INFO] Device found: [%s:%d]%s", entryAddr(found), found.Port, found.Name)
+	device := newCastDevice(found, o.deviceOptions())
+	if err := device.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// connectAddr connects to target if it is an address. ok is false if it is not.
+func connectAddr(ctx context.Context, target string) (device *CastDevice, ok bool, err error) {
+	host, port := target, defaultCastPort
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		host = h
+		if port, err = strconv.Atoi(p); err != nil {
+			return nil, false, nil
+		}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, false, nil
+	}
+
+	device = newCastDevice(addrEntry(target, ip, port), nil)
+	if err := device.Connect(ctx); err != nil {
+		return nil, true, err
+	}
+	return device, true, nil
+}
+
+// describeDevice adds name of the device to err unless it is empty
+func describeDevice(err error, name string) error {
+	if name == "" {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, name)
+}
@@ -0,0 +1,131 @@
+package homecast
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Device is a speaker which plays media. CastDevice and SimulatedDevice implement it.
+type Device interface {
+	Speak(ctx context.Context, text, lang string, opts ...PlayOption) error
+	Play(ctx context.Context, url *url.URL, opts ...PlayOption) error
+	Close()
+}
+
+var (
+	_ Device = (*CastDevice)(nil)
+	_ Device = (*SimulatedDevice)(nil)
+)
+
+// simulatedBufferTime is how long SimulatedDevice buffers media before playing it
+const simulatedBufferTime = 500 * time.Millisecond
+
+// SimulatedDevice is a Device which pretends to play media without any speaker,
+// for developing applications off the network.
+// Sessions and media status changes, from BUFFERING to PLAYING to IDLE, are published on the event bus
+// and passed to OnComplete as CastDevice does, with nil Device. WithWait blocks until playback ends.
+type SimulatedDevice struct {
+	Name string
+	// Duration is how long media given to Play plays. Default is 3 seconds.
+	Duration time.Duration
+	// SpeechRate is how long each character of text given to Speak takes. Default is 70ms.
+	SpeechRate time.Duration
+	// Fail injects failure. When it returns an error, loading media of contentID fails with it.
+	Fail func(contentID string) error
+	// Clock is used to wait for playback. Default is the system clock.
+	Clock Clock
+	// Logger logs simulated playback. Default logs nothing.
+	Logger Logger
+
+	initOnce sync.Once
+	// ctx is done when the device is closed, to stop playback not waited for
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Speak pretends to speak text for a duration proportional to its length
+func (d *SimulatedDevice) Speak(ctx context.Context, text, lang string, opts ...PlayOption) error {
+	u, err := GoogleTranslate{}.Synthesize(ctx, text, lang)
+	if err != nil {
+		return err
+	}
+	rate := d.SpeechRate
+	if rate == 0 {
+		rate = charSpeechTime
+	}
+	return d.play(ctx, u, time.Duration(len([]rune(text)))*rate, opts)
+}
+
+// Play pretends to play media contents
+func (d *SimulatedDevice) Play(ctx context.Context, url *url.URL, opts ...PlayOption) error {
+	duration := d.Duration
+	if duration == 0 {
+		duration = 3 * time.Second
+	}
+	return d.play(ctx, url, duration, opts)
+}
+
+func (d *SimulatedDevice) play(ctx context.Context, u *url.URL, duration time.Duration, opts []PlayOption) error {
+	d.init()
+	o := newPlayOptions(opts)
+	contentID := u.String()
+
+	if d.Fail != nil {
+		if err := d.Fail(contentID); err != nil {
+			o.complete(o.session(nil, contentID, err))
+			return err
+		}
+	}
+
+	logf(d.Logger, "[INFO] Simulated load media: device=%s content_id=%s", d.Name, contentID)
+	status := MediaStatus{PlayerState: "BUFFERING", ContentID: contentID, Duration: duration}
+	publish(MediaStatusChanged{Status: status})
+	publish(SessionStarted{o.session(nil, contentID, nil)})
+
+	clock := d.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	run := func(ctx context.Context) error {
+		for _, next := range []struct {
+			wait  time.Duration
+			state string
+		}{{simulatedBufferTime, "PLAYING"}, {duration, "IDLE"}} {
+			select {
+			case <-clock.After(next.wait):
+			case <-ctx.Done():
+				status.PlayerState, status.IdleReason = "IDLE", "CANCELLED"
+				publish(MediaStatusChanged{Status: status})
+				o.complete(o.session(nil, contentID, ctx.Err()))
+				return ctx.Err()
+			}
+			status.PlayerState = next.state
+			if next.state == "IDLE" {
+				status.IdleReason, status.Position = "FINISHED", duration
+			}
+			publish(MediaStatusChanged{Status: status})
+		}
+		o.complete(o.session(nil, contentID, nil))
+		return nil
+	}
+	if o.wait {
+		return run(ctx)
+	}
+	// Keep playing after return until the device is closed; ctx may end with the caller
+	go run(d.ctx)
+	return nil
+}
+
+// Close stops playback not waited for
+func (d *SimulatedDevice) Close() {
+	d.init()
+	d.cancel()
+}
+
+func (d *SimulatedDevice) init() {
+	d.initOnce.Do(func() {
+		d.ctx, d.cancel = context.WithCancel(context.Background())
+	})
+}
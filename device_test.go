@@ -0,0 +1,68 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSimulatedDeviceWait(t *testing.T) {
+	clock := newFakeClock()
+	d := &SimulatedDevice{Name: "sim", Duration: 10 * time.Second, Clock: clock}
+	defer d.Close()
+	u, _ := url.Parse("http://example.com/a.mp3")
+
+	var mu sync.Mutex
+	var states []string
+	unsubscribe := Subscribe(func(e Event) {
+		if e, ok := e.(MediaStatusChanged); ok && e.Status.ContentID == u.String() {
+			mu.Lock()
+			states = append(states, e.Status.PlayerState+e.Status.IdleReason)
+			mu.Unlock()
+		}
+	})
+	defer unsubscribe()
+
+	done := make(chan error, 1)
+	go func() { done <- d.Play(context.Background(), u, WithWait()) }()
+
+	clock.BlockUntil(t, 1)
+	clock.Advance(simulatedBufferTime)
+	clock.BlockUntil(t, 1)
+	select {
+	case err := <-done:
+		t.Fatalf("Play returned before media ended: %v", err)
+	default:
+	}
+	clock.Advance(10 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Play: %v", err)
+	}
+
+	want := []string{"BUFFERING", "PLAYING", "IDLEFINISHED"}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(states) != len(want) {
+		t.Fatalf("states = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Errorf("states = %v, want %v", states, want)
+		}
+	}
+}
+
+func TestSimulatedDeviceCancel(t *testing.T) {
+	d := &SimulatedDevice{Clock: newFakeClock()}
+	defer d.Close()
+	u, _ := url.Parse("http://example.com/b.mp3")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := d.Play(ctx, u, WithWait()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Play = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,225 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/barnybug/go-cast/events"
+)
+
+// statusPollInterval is how often Events asks the receiver for media status
+const statusPollInterval = 2 * time.Second
+
+// DeviceConnected is sent by Events when connection to the device is established
+type DeviceConnected struct {
+	Device *CastDevice
+}
+
+// DeviceDisconnected is sent by Events when connection to the device is lost
+type DeviceDisconnected struct {
+	Device *CastDevice
+	Err    error
+}
+
+// AppStarted is sent by Events when an app is launched on the device
+type AppStarted struct {
+	Device      *CastDevice
+	AppID       string
+	DisplayName string
+}
+
+// AppStopped is sent by Events when an app on the device is closed
+type AppStopped struct {
+	Device      *CastDevice
+	AppID       string
+	DisplayName string
+}
+
+// VolumeChanged is sent by Events when volume of the device changed
+type VolumeChanged struct {
+	Device *CastDevice
+	Volume float64
+	Muted  bool
+}
+
+// MediaStatusChanged is sent by Events when player state or loaded media changed,
+// including when playback is paused or stopped from other senders such as the Google Home app
+type MediaStatusChanged struct {
+	Device *CastDevice
+	Status MediaStatus
+}
+
+// Events emits receiver, media and connection status changes of cast device.
+// It may be called more than once, such as by several consumers; events are sent to every channel.
+// The channel is closed when ctx is done.
+func (g *CastDevice) Events(ctx context.Context) (<-chan Event, error) {
+	h := &g.events
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		p := &statusPoller{device: g}
+		status, err := p.poll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pumpCtx, cancel := context.WithCancel(context.Background())
+		h.subs = map[*eventSub]bool{}
+		h.cancel = cancel
+		go g.pumpEvents(pumpCtx, p, status)
+	}
+
+	sub := &eventSub{ctx: ctx, ch: make(chan Event)}
+	h.subs[sub] = true
+	go func() {
+		<-ctx.Done()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if !h.subs[sub] {
+			return
+		}
+		delete(h.subs, sub)
+		close(sub.ch)
+		if len(h.subs) == 0 {
+			h.stop()
+		}
+	}()
+	return sub.ch, nil
+}
+
+// eventHub fans out events of cast device to channels returned by Events.
+// Its pump runs while there are subscribers.
+type eventHub struct {
+	mu     sync.Mutex
+	subs   map[*eventSub]bool
+	cancel context.CancelFunc
+}
+
+type eventSub struct {
+	ctx context.Context
+	ch  chan Event
+}
+
+// stop stops the pump and closes channels of subscribers. mu must be held.
+func (h *eventHub) stop() {
+	for sub := range h.subs {
+		close(sub.ch)
+	}
+	h.subs = nil
+	h.cancel()
+}
+
+// send sends e to every subscriber, unless pump of ctx was already stopped
+func (h *eventHub) send(ctx context.Context, e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	for sub := range h.subs {
+		select {
+		case sub.ch <- e:
+		case <-sub.ctx.Done():
+		}
+	}
+}
+
+// pumpEvents converts events of the connection and polls media status until ctx is done
+func (g *CastDevice) pumpEvents(ctx context.Context, p *statusPoller, status *MediaStatus) {
+	defer p.close()
+	ticks, stop := tick(g.clk(), statusPollInterval)
+	defer stop()
+	for {
+		var e Event
+		select {
+		case <-ctx.Done():
+			return
+		case ce, ok := <-g.castClient().Events:
+			if !ok {
+				g.events.mu.Lock()
+				if ctx.Err() == nil {
+					g.events.stop()
+				}
+				g.events.mu.Unlock()
+				return
+			}
+			e = g.convertEvent(ce)
+			switch e.(type) {
+			case AppStarted, AppStopped, DeviceDisconnected:
+				// Media session of another app, or of the closed one, would report stale status
+				p.close()
+			}
+		case <-ticks:
+			st, err := p.poll(ctx)
+			if err != nil {
+				g.logf("[ERROR] Failed to get status: %v", err)
+				continue
+			}
+			if mediaChanged(status, st) {
+				e = MediaStatusChanged{Device: g, Status: *st}
+			}
+			status = st
+		}
+		if e != nil {
+			g.events.send(ctx, e)
+		}
+	}
+}
+
+// statusPoller polls status of cast device through one media session kept open between polls,
+// instead of connecting to the media receiver every time
+type statusPoller struct {
+	device  *CastDevice
+	session *mediaSession
+}
+
+func (p *statusPoller) poll(ctx context.Context) (*MediaStatus, error) {
+	st, err := p.device.volumeStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.session == nil {
+		s, err := p.device.openMedia(ctx, false)
+		if errors.Is(err, ErrNoMediaSession) {
+			return st, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.session = s
+	}
+	if err := p.session.fillStatus(ctx, st); err != nil {
+		p.close()
+		return nil, err
+	}
+	return st, nil
+}
+
+func (p *statusPoller) close() {
+	if p.session != nil {
+		p.session.Close()
+		p.session = nil
+	}
+}
+
+// convertEvent converts event of go-cast, returning nil for unknown ones
+func (g *CastDevice) convertEvent(e events.Event) Event {
+	switch e := e.(type) {
+	case events.Connected:
+		return DeviceConnected{Device: g}
+	case events.Disconnected:
+		return DeviceDisconnected{Device: g, Err: e.Reason}
+	case events.AppStarted:
+		return AppStarted{Device: g, AppID: e.AppID, DisplayName: e.DisplayName}
+	case events.AppStopped:
+		return AppStopped{Device: g, AppID: e.AppID, DisplayName: e.DisplayName}
+	case events.StatusUpdated:
+		return VolumeChanged{Device: g, Volume: e.Level, Muted: e.Muted}
+	}
+	return nil
+}
+
+func mediaChanged(a, b *MediaStatus) bool {
+	return a.PlayerState != b.PlayerState || a.ContentID != b.ContentID || a.IdleReason != b.IdleReason
+}
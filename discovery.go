@@ -0,0 +1,223 @@
+package homecast
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/micro/mdns"
+)
+
+// DiscoverOptions are parameters of discovery given to discovery backends
+type DiscoverOptions struct {
+	// ServiceName is mDNS service to browse. Default is "_googlecast._tcp".
+	ServiceName string
+	// Timeout is how long to wait for responses. Default is the mdns package default.
+	Timeout time.Duration
+	// Interface is network interface to query on. Default is the system default for multicast.
+	Interface *net.Interface
+	// IPv6 enables querying and connecting over IPv6
+	IPv6 bool
+	// DeviceOptions configure found devices
+	DeviceOptions []Option
+	// Pool limits how many devices are connected to concurrently. DefaultPool is used when nil.
+	Pool *Pool
+	// Logger logs progress of discovery, and of found devices unless DeviceOptions set another. Default logs nothing.
+	Logger Logger
+	// Clock is used to wait between scans of Watch. Default is the system clock.
+	Clock Clock
+}
+
+// DiscoverOption configures discovery
+type DiscoverOption func(*DiscoverOptions)
+
+// WithTimeout sets how long to wait for mDNS responses
+func WithTimeout(d time.Duration) DiscoverOption {
+	return func(o *DiscoverOptions) {
+		o.Timeout = d
+	}
+}
+
+// WithInterface sets network interface to query on, for multi-homed hosts
+func WithInterface(iface *net.Interface) DiscoverOption {
+	return func(o *DiscoverOptions) {
+		o.Interface = iface
+	}
+}
+
+// WithIPv6 enables discovering and connecting devices over IPv6
+func WithIPv6() DiscoverOption {
+	return func(o *DiscoverOptions) {
+		o.IPv6 = true
+	}
+}
+
+// WithServiceName sets mDNS service name to browse
+func WithServiceName(name string) DiscoverOption {
+	return func(o *DiscoverOptions) {
+		o.ServiceName = name
+	}
+}
+
+// WithDeviceOptions configures found devices with opts, such as WithAutoReconnect
+func WithDeviceOptions(opts ...Option) DiscoverOption {
+	return func(o *DiscoverOptions) {
+		o.DeviceOptions = append(o.DeviceOptions, opts...)
+	}
+}
+
+// WithPool sets pool which limits how many devices are connected to concurrently
+func WithPool(p *Pool) DiscoverOption {
+	return func(o *DiscoverOptions) {
+		o.Pool = p
+	}
+}
+
+// WithDiscoveryLogger sets logger of discovery and found devices
+func WithDiscoveryLogger(l Logger) DiscoverOption {
+	return func(o *DiscoverOptions) {
+		o.Logger = l
+	}
+}
+
+// WithDiscoveryClock sets clock used to wait between scans of Watch
+func WithDiscoveryClock(c Clock) DiscoverOption {
+	return func(o *DiscoverOptions) {
+		o.Clock = c
+	}
+}
+
+// deviceOptions returns options of found devices, which inherit logger of discovery
+func (o *DiscoverOptions) deviceOptions() []Option {
+	return append([]Option{WithLogger(o.Logger)}, o.DeviceOptions...)
+}
+
+func newDiscoverOptions(opts []DiscoverOption) *DiscoverOptions {
+	o := &DiscoverOptions{ServiceName: googleCastServiceName}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.Pool == nil {
+		o.Pool = DefaultPool
+	}
+	if o.Clock == nil {
+		o.Clock = realClock{}
+	}
+	return o
+}
+
+// ConnectError is failure of connecting to a discovered device
+type ConnectError struct {
+	Entry *mdns.ServiceEntry
+	Err   error
+}
+
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("homecast: failed to connect to %s: %v", e.Entry.Name, e.Err)
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// LookupError describes failures during LookupAndConnect, Lookup and LookupDevice.
+// Devices which were connected are still returned along with it.
+type LookupError struct {
+	// Discovery holds failures of discovery backends, such as broken network
+	Discovery []error
+	// Connect holds failures of connecting to each discovered device
+	Connect []*ConnectError
+}
+
+func (e *LookupError) Error() string {
+	msgs := make([]string, 0, len(e.Discovery)+len(e.Connect))
+	for _, err := range e.Discovery {
+		msgs = append(msgs, "homecast: discovery failed: "+err.Error())
+	}
+	for _, err := range e.Connect {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns all failures, so that errors.Is and errors.As look into each of them
+func (e *LookupError) Unwrap() []error {
+	errs := append([]error{}, e.Discovery...)
+	for _, err := range e.Connect {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// discover runs registered discovery backends and sends found entries to entriesCh.
+// It returns failures of backends.
+func discover(ctx context.Context, o *DiscoverOptions, entriesCh chan<- *mdns.ServiceEntry) []error {
+	var errs []error
+	for _, d := range discoverers() {
+		if ctx.Err() != nil {
+			return append(errs, ctx.Err())
+		}
+		if err := d.Discover(ctx, o, entriesCh); err != nil {
+			logf(o.Logger, "[ERROR] Discovery failed: %v", err)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+type mdnsDiscoverer struct{}
+
+// Discover queries mDNS until timeout or ctx is done, whichever comes first
+func (mdnsDiscoverer) Discover(ctx context.Context, o *DiscoverOptions, entries chan<- *mdns.ServiceEntry) error {
+	params := mdns.DefaultParams(o.ServiceName)
+	params.Interface = o.Interface
+	params.DisableIPv6 = !o.IPv6
+	if o.Timeout > 0 {
+		params.Timeout = o.Timeout
+	}
+	if deadline, ok := ctx.Deadline(); ok && (params.Timeout == 0 || time.Until(deadline) < params.Timeout) {
+		params.Timeout = time.Until(deadline)
+	}
+
+	// mdns.Query can't be cancelled, so entries are forwarded until ctx is done
+	// and the rest is drained after that
+	found := make(chan *mdns.ServiceEntry, 4)
+	params.Entries = found
+	done := make(chan error, 1)
+	go func() {
+		done <- mdns.Query(params)
+		close(found)
+	}()
+	for {
+		select {
+		case entry, ok := <-found:
+			if !ok {
+				return <-done
+			}
+			select {
+			case entries <- entry:
+			case <-ctx.Done():
+				go drain(found)
+				return ctx.Err()
+			}
+		case <-ctx.Done():
+			go drain(found)
+			return ctx.Err()
+		}
+	}
+}
+
+func drain(ch <-chan *mdns.ServiceEntry) {
+	for range ch {
+	}
+}
+
+// entryAddr returns address to connect to the entry, preferring IPv4
+func entryAddr(entry *mdns.ServiceEntry) net.IP {
+	if entry.AddrV4 != nil {
+		return entry.AddrV4
+	}
+	return entry.AddrV6
+}
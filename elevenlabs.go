@@ -0,0 +1,73 @@
+package homecast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const elevenLabsEndpoint = "https://api.elevenlabs.io/v1/text-to-speech/"
+
+// ElevenLabs is TTSProvider using ElevenLabs streaming text-to-speech.
+// Audio is served to cast devices by MediaServer while it is still being synthesized,
+// so that long messages start playing sooner.
+type ElevenLabs struct {
+	APIKey string
+	// VoiceID is id of the voice to speak with
+	VoiceID string
+	// ModelID such as "eleven_multilingual_v2". Default model of the account is used when empty.
+	ModelID string
+	// Server serves synthesized audio. DefaultMediaServer is used when nil.
+	Server *MediaServer
+}
+
+// Synthesize starts synthesizing text and returns url of the audio streamed locally
+func (t *ElevenLabs) Synthesize(ctx context.Context, text, lang string) (*url.URL, error) {
+	audio, contentType, err := t.SynthesizeStream(ctx, text, lang)
+	if err != nil {
+		return nil, err
+	}
+	server := t.Server
+	if server == nil {
+		server = DefaultMediaServer
+	}
+	return server.ServeReader(audio, contentType)
+}
+
+// SynthesizeStream starts synthesizing text and returns audio read as it is synthesized.
+// Synthesis continues after ctx is done, until audio is read to the end or closed.
+func (t *ElevenLabs) SynthesizeStream(ctx context.Context, text, lang string) (io.ReadCloser, string, error) {
+	body := map[string]string{"text": text}
+	if t.ModelID != "" {
+		body["model_id"] = t.ModelID
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, elevenLabsEndpoint+url.PathEscape(t.VoiceID)+"/stream", bytes.NewReader(b))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "audio/mpeg")
+	req.Header.Set("xi-api-key", t.APIKey)
+	// The device fetches audio after Speak returns, so the request must outlive ctx
+	resp, err := http.DefaultClient.Do(req.WithContext(context.WithoutCancel(ctx)))
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("homecast: elevenlabs returned %s", resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "audio/mpeg"
+	}
+	return resp.Body, contentType, nil
+}
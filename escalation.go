@@ -0,0 +1,112 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrNotAcknowledged is returned by Escalation when no step of it was acknowledged
+var ErrNotAcknowledged = errors.New("homecast: announcement not acknowledged")
+
+// EscalationStep is a stage of Escalation
+type EscalationStep struct {
+	Devices []Device
+	// Volume is volume level to speak at. Zero keeps volume of the devices.
+	Volume float64
+	// Timeout is how long to wait for acknowledgement after speaking, before the next step
+	Timeout time.Duration
+}
+
+// Escalation speaks critical alerts on devices of the first step, and replays them on
+// the next step, such as a wider group at higher volume, until acknowledged by Ack
+type Escalation struct {
+	Steps []EscalationStep
+	// Clock is used to wait for acknowledgement. Default is the system clock.
+	Clock Clock
+	// Logger logs progress of escalation. Default logs nothing.
+	Logger Logger
+
+	mu      sync.Mutex
+	pending map[string]chan struct{}
+}
+
+// Speak speaks text step by step until Ack is called with key, or ctx is done.
+// It returns ErrNotAcknowledged when the last step timed out.
+func (e *Escalation) Speak(ctx context.Context, key, text, lang string, opts ...PlayOption) error {
+	acked := e.register(key)
+	defer e.unregister(key, acked)
+
+	for i, step := range e.Steps {
+		stepOpts := append(opts[:len(opts):len(opts)], WithWait())
+		if step.Volume > 0 {
+			stepOpts = append(stepOpts, WithVolume(step.Volume))
+		}
+		logf(e.Logger, "[INFO] Escalation step: key=%s step=%d devices=%d", key, i+1, len(step.Devices))
+		b := &Broadcast{Devices: step.Devices}
+		if err := b.Speak(ctx, text, lang, stepOpts...); err != nil {
+			logf(e.Logger, "[ERROR] Failed to speak escalation step: key=%s step=%d err=%v", key, i+1, err)
+		}
+
+		select {
+		case <-acked:
+			logf(e.Logger, "[INFO] Escalation acknowledged: key=%s step=%d", key, i+1)
+			return nil
+		case <-e.clk().After(step.Timeout):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNotAcknowledged, key)
+}
+
+// Ack acknowledges announcement of key, which stops its escalation.
+// It returns false if no announcement of key is escalating.
+func (e *Escalation) Ack(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ch, ok := e.pending[key]
+	if !ok {
+		return false
+	}
+	close(ch)
+	delete(e.pending, key)
+	return true
+}
+
+// ServeHTTP acknowledges announcement of key given by "key" parameter, to be used as webhook
+func (e *Escalation) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !e.Ack(r.FormValue("key")) {
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (e *Escalation) register(key string) chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pending == nil {
+		e.pending = map[string]chan struct{}{}
+	}
+	ch := make(chan struct{})
+	e.pending[key] = ch
+	return ch
+}
+
+// unregister removes ch of key unless it was acknowledged or replaced already
+func (e *Escalation) unregister(key string, ch chan struct{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.pending[key] == ch {
+		delete(e.pending, key)
+	}
+}
+
+func (e *Escalation) clk() Clock {
+	if e.Clock == nil {
+		return realClock{}
+	}
+	return e.Clock
+}
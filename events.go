@@ -0,0 +1,91 @@
+package homecast
+
+import "sync"
+
+// Event is published on the package event bus, or sent by CastDevice.Events.
+// It is one of the event types below or in deviceevents.go.
+type Event interface{}
+
+// DeviceFound is published when a device is discovered and connected
+type DeviceFound struct {
+	Device *CastDevice
+}
+
+// SessionStarted is published when media is loaded on a device
+type SessionStarted struct {
+	Session Session
+}
+
+// SessionEnded is published when loaded media finished or failed.
+// It is not published for live streams, unless Play is given OnComplete or WithWait.
+type SessionEnded struct {
+	Session Session
+}
+
+// TrackFinished is published by RecordPlayback when a track ended
+type TrackFinished struct {
+	Stats TrackStats
+}
+
+// DeviceOffline is published by Monitor when a device stopped responding
+type DeviceOffline struct {
+	Device *CastDevice
+	Err    error
+}
+
+// DeviceOnline is published by Monitor when an offline device responds again
+type DeviceOnline struct {
+	Device *CastDevice
+}
+
+// SessionTakenOver is published when another sender replaced media of a session
+type SessionTakenOver struct {
+	Session Session
+}
+
+// ErrorEvent is published on failures outside of media sessions, such as connection failure
+type ErrorEvent struct {
+	Device *CastDevice
+	Err    error
+}
+
+// DiscoveryFinished is published when a discovery scan finished
+type DiscoveryFinished struct {
+	Stats DiscoveryStats
+}
+
+var (
+	busMu            sync.RWMutex
+	subscribers      = map[int]func(Event){}
+	nextSubscriberID int
+)
+
+// Subscribe registers f to receive every event published by this package.
+// f is called synchronously from the publishing goroutine, so it must not block.
+// Call returned func to unsubscribe.
+func Subscribe(f func(Event)) (unsubscribe func()) {
+	busMu.Lock()
+	defer busMu.Unlock()
+	id := nextSubscriberID
+	nextSubscriberID++
+	subscribers[id] = f
+	return func() {
+		busMu.Lock()
+		delete(subscribers, id)
+		busMu.Unlock()
+	}
+}
+
+func hasSubscribers() bool {
+	busMu.RLock()
+	defer busMu.RUnlock()
+	return len(subscribers) > 0
+}
+
+func publish(e Event) {
+	busMu.RLock()
+	defer busMu.RUnlock()
+	for _, f := range subscribers {
+		f(e)
+	}
+}
@@ -9,7 +9,10 @@ import (
 
 func main() {
 	ctx := context.Background()
-	devices := homecast.LookupAndConnect(ctx)
+	devices, err := homecast.LookupAndConnect(ctx)
+	if err != nil {
+		fmt.Printf("Lookup failed: %v\n", err)
+	}
 
 	for _, device := range devices {
 		fmt.Printf("Device: [%s:%d]%s", device.AddrV4, device.Port, device.Name)
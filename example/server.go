@@ -2,30 +2,218 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/ikasamah/homecast"
 )
 
+// config is reloaded from -config file on SIGHUP, along with -import-devices file.
+// Every key is reloaded; flags, such as -port and -journal, are read only on start.
+type config struct {
+	// Lang is default language to speak
+	Lang string `json:"lang"`
+	// Devices are friendly names of devices, speaker groups or aliases to speak on. Empty means all devices.
+	Devices []string `json:"devices"`
+	// Aliases name a mix of speaker groups and devices, such as {"downstairs": ["Living room group", "Kitchen speaker"]}
+	Aliases map[string][]string `json:"aliases"`
+	// MaxVolume is volume ceiling applied to all devices. Zero disables it.
+	MaxVolume float64 `json:"max_volume"`
+	// VolumeSchedule is default volume of announcements by time of day, such as [{"hour": 22, "level": 0.15}]
+	VolumeSchedule homecast.VolumeSchedule `json:"volume_schedule"`
+	// RepeatKeyword is text which repeats the last announcement instead of being spoken
+	RepeatKeyword string `json:"repeat_keyword"`
+}
+
+func loadConfig(path, defaultLang string) (*config, error) {
+	cfg := &config{Lang: defaultLang}
+	if path == "" {
+		return cfg, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func importDevices(path string, devices []*homecast.CastDevice) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return homecast.ImportConfig(f, devices)
+}
+
+func exportDevices(path string, devices []*homecast.CastDevice) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := homecast.ExportConfig(f, devices); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 func main() {
 	port := flag.Int("port", 8080, "Listen port")
 	defaultLang := flag.String("lang", "en", "Default language to speak")
+	journalPath := flag.String("journal", "", "Journal file to replay undelivered announcements after restart")
+	drain := flag.String("drain", "finish", "What to do with in-flight announcements on shutdown: finish or cancel")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "Deadline to shut down gracefully")
+	configPath := flag.String("config", "", "JSON config file, reloaded on SIGHUP")
+	exportPath := flag.String("export-devices", "", "Write device settings and aliases to the file and exit")
+	importPath := flag.String("import-devices", "", "Device settings and aliases file written by -export-devices on another host")
+	historyDir := flag.String("history-dir", "", "Directory to keep announcement history of each device across restarts")
 	flag.Parse()
 
-	ctx := context.Background()
-	devices := homecast.LookupAndConnect(ctx)
+	cfg, err := loadConfig(*configPath, *defaultLang)
+	if err != nil {
+		log.Fatal("loadConfig: ", err)
+	}
+	var cfgMu sync.RWMutex
+
+	// ctx is cancelled to abort in-flight announcements on shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	devices, err := homecast.LookupAndConnect(ctx, homecast.WithDiscoveryLogger(log.Default()))
+	if err != nil {
+		log.Print("[ERROR] LookupAndConnect: ", err)
+	}
 	defer func() {
 		for _, device := range devices {
 			device.Close()
 		}
 	}()
+	if *historyDir != "" {
+		for _, device := range devices {
+			if err := device.SetHistoryFile(filepath.Join(*historyDir, device.ID()+".jsonl")); err != nil {
+				log.Printf("[ERROR] Failed to open history of %s: %v", device.Name, err)
+			}
+		}
+	}
+
+	// registered are aliases registered by the last applied config, to remove ones dropped on reload
+	var registered map[string][]string
+	applyConfig := func() {
+		for _, device := range devices {
+			device.SetMaxVolume(cfg.MaxVolume)
+			device.SetVolumeSchedule(cfg.VolumeSchedule)
+		}
+		for alias := range registered {
+			if _, ok := cfg.Aliases[alias]; !ok {
+				homecast.UnregisterAlias(alias)
+			}
+		}
+		for alias, names := range cfg.Aliases {
+			homecast.RegisterAlias(alias, names...)
+		}
+		registered = cfg.Aliases
+		// Settings imported per device override the config
+		if *importPath != "" {
+			if err := importDevices(*importPath, devices); err != nil {
+				log.Printf("[ERROR] Failed to import devices: %v", err)
+			}
+		}
+	}
+	applyConfig()
+
+	if *exportPath != "" {
+		if err := exportDevices(*exportPath, devices); err != nil {
+			log.Fatal("exportDevices: ", err)
+		}
+		return
+	}
+
+	// Reload config without dropping device connections
+	go func() {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		for range hup {
+			newCfg, err := loadConfig(*configPath, *defaultLang)
+			if err != nil {
+				log.Printf("[ERROR] Failed to reload config: %v", err)
+				continue
+			}
+			cfgMu.Lock()
+			cfg = newCfg
+			applyConfig()
+			cfgMu.Unlock()
+			log.Printf("[INFO] Config reloaded")
+		}
+	}()
+
+	// targets returns devices to speak on according to config
+	targets := func() []*homecast.CastDevice {
+		cfgMu.RLock()
+		defer cfgMu.RUnlock()
+		if len(cfg.Devices) == 0 {
+			return devices
+		}
+		return homecast.SelectTargets(ctx, devices, cfg.Devices...)
+	}
+
+	// speak returns the last error of devices failed to speak
+	speak := func(text, lang string) error {
+		var lastErr error
+		for _, device := range targets() {
+			if err := device.Speak(ctx, text, lang); err != nil {
+				log.Printf("[ERROR] Failed to speak: %v", err)
+				lastErr = err
+			}
+		}
+		return lastErr
+	}
+
+	repeat := func() {
+		for _, device := range targets() {
+			if err := device.Repeat(ctx); err != nil {
+				log.Printf("[ERROR] Failed to repeat: %v", err)
+			}
+		}
+	}
+
+	var journal *homecast.Journal
+	if *journalPath != "" {
+		if journal, err = homecast.OpenJournal(*journalPath); err != nil {
+			log.Fatal("OpenJournal: ", err)
+		}
+		defer journal.Close()
+
+		for _, e := range journal.Pending() {
+			log.Printf("[INFO] Replay announcement: key=%s", e.Key)
+			if err := speak(e.Text, e.Lang); err != nil {
+				// Left pending to be replayed after next restart
+				continue
+			}
+			if err := journal.Done(e.Key); err != nil {
+				log.Printf("[ERROR] Failed to write journal: %v", err)
+			}
+		}
+	}
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		text := r.FormValue("text")
 		lang := r.FormValue("lang")
+		key := r.FormValue("key")
 
 		if text == "" {
 			log.Printf("[INFO] Skip request due to no text given")
@@ -33,19 +221,105 @@ func main() {
 			return
 		}
 
+		cfgMu.RLock()
+		keyword := cfg.RepeatKeyword
 		if lang == "" {
-			lang = *defaultLang
+			lang = cfg.Lang
+		}
+		cfgMu.RUnlock()
+
+		if keyword != "" && strings.EqualFold(strings.TrimSpace(text), keyword) {
+			repeat()
+			return
+		}
+
+		if journal == nil {
+			if err := speak(text, lang); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
 		}
 
+		if key == "" {
+			key = strconv.FormatInt(time.Now().UnixNano(), 36)
+		}
+		ok, err := journal.Append(homecast.JournalEntry{Key: key, Text: text, Lang: lang})
+		if err != nil {
+			log.Printf("[ERROR] Failed to write journal: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			log.Printf("[INFO] Skip duplicated request: key=%s", key)
+			return
+		}
+		if err := speak(text, lang); err != nil {
+			// Left pending to be replayed after restart
+			if ctx.Err() != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			} else {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+		if err := journal.Done(key); err != nil {
+			log.Printf("[ERROR] Failed to write journal: %v", err)
+		}
+	})
+
+	http.HandleFunc("/repeat", func(w http.ResponseWriter, r *http.Request) {
+		repeat()
+	})
+
+	http.HandleFunc("/history", func(w http.ResponseWriter, r *http.Request) {
+		type entry struct {
+			Device   string    `json:"device"`
+			Text     string    `json:"text"`
+			Lang     string    `json:"lang"`
+			Time     time.Time `json:"time"`
+			Duration string    `json:"duration"`
+			Error    string    `json:"error,omitempty"`
+		}
+		entries := make([]entry, 0)
 		for _, device := range devices {
-			if err := device.Speak(ctx, text, lang); err != nil {
-				log.Printf("[ERROR] Failed to speak: %v", err)
+			for _, a := range device.History() {
+				e := entry{Device: device.Name, Text: a.Text, Lang: a.Lang, Time: a.Time, Duration: a.Duration.String()}
+				if a.Err != nil {
+					e.Error = a.Err.Error()
+				}
+				entries = append(entries, e)
 			}
 		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
 	})
 
-	addr := fmt.Sprintf(":%d", *port)
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	server := &http.Server{Addr: fmt.Sprintf(":%d", *port)}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+		<-sig
+		log.Printf("[INFO] Shutting down: drain=%s", *drain)
+
+		if *drain == "cancel" {
+			cancel()
+		}
+		shutdownCtx, done := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer done()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("[ERROR] Failed to shut down gracefully: %v", err)
+			cancel()
+			server.Close()
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatal("ListenAndServe: ", err)
 	}
+	// Shutdown returns after in-flight requests are drained
+	<-stopped
+	// Deferred funcs flush journal and close device connections
+	log.Printf("[INFO] Server stopped")
 }
@@ -0,0 +1,89 @@
+package homecast
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+)
+
+// Extractor converts a page url into a direct media stream url
+type Extractor interface {
+	Extract(ctx context.Context, u *url.URL) (*url.URL, error)
+}
+
+// ErrUnknownExtractor is returned when extractor given by WithExtractor is not registered
+var ErrUnknownExtractor = errors.New("homecast: unknown extractor")
+
+// WithExtractor makes Play resolve url with extractor registered by name with RegisterExtractor,
+// instead of the one set by SetExtractor
+func WithExtractor(name string) PlayOption {
+	return func(o *playOptions) {
+		o.extractor = name
+	}
+}
+
+// extractorFor returns extractor selected by WithExtractor, or the one of cast device which may be nil
+func (g *CastDevice) extractorFor(o playOptions) (Extractor, error) {
+	if o.extractor == "" {
+		return g.currentSettings().extractor, nil
+	}
+	e, ok := LookupExtractor(o.extractor)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownExtractor, o.extractor)
+	}
+	return e, nil
+}
+
+// resolve converts url with extractor selected by play options if any
+func (g *CastDevice) resolve(ctx context.Context, u *url.URL, o playOptions) (*url.URL, error) {
+	extractor, err := g.extractorFor(o)
+	if err != nil || extractor == nil {
+		return u, err
+	}
+	extracted, err := extractor.Extract(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	g.logf("[INFO] Extracted media: %s -> %s", u, extracted)
+	return extracted, nil
+}
+
+// YTDLP is an Extractor which delegates to the yt-dlp command
+type YTDLP struct {
+	// Path is the yt-dlp executable. "yt-dlp" is looked up in $PATH when empty.
+	Path string
+	// Format is the format selector given to yt-dlp. "bestaudio" when empty.
+	Format string
+}
+
+// Extract runs yt-dlp and returns the first stream url it prints
+func (y *YTDLP) Extract(ctx context.Context, u *url.URL) (*url.URL, error) {
+	path := y.Path
+	if path == "" {
+		path = "yt-dlp"
+	}
+	format := y.Format
+	if format == "" {
+		format = "bestaudio"
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "--get-url", "--no-playlist", "-f", format, u.String())
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("yt-dlp: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			return url.Parse(string(line))
+		}
+	}
+	return nil, fmt.Errorf("yt-dlp: no stream url found for %s", u)
+}
@@ -0,0 +1,128 @@
+package homecast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// localAPIPort is the port of setup API served by cast devices
+const localAPIPort = 8008
+
+// ErrUnsupportedByDevice is returned when the feature is known to misbehave on the device's firmware
+var ErrUnsupportedByDevice = errors.New("homecast: unsupported by device firmware")
+
+// minFirmware is the oldest cast firmware each feature works on, guarded by registryMu
+var minFirmware = map[string]string{
+	// Queue commands are ignored by receivers without queue support
+	"queue": "1.14",
+}
+
+// SetMinFirmware sets the oldest cast firmware feature, such as "queue", works on.
+// The feature is refused with ErrUnsupportedByDevice on older firmware. Empty version removes the gate.
+// Queue commands need 1.14 by default.
+func SetMinFirmware(feature, version string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if version == "" {
+		delete(minFirmware, feature)
+		return
+	}
+	minFirmware[feature] = version
+}
+
+type firmware struct {
+	mu      sync.Mutex
+	version string
+}
+
+// FirmwareVersion returns cast firmware version of the device, such as "1.42.172094".
+// It is queried from the device's local setup API and cached once it succeeded.
+func (g *CastDevice) FirmwareVersion(ctx context.Context) (string, error) {
+	g.firmware.mu.Lock()
+	defer g.firmware.mu.Unlock()
+	if g.firmware.version != "" {
+		return g.firmware.version, nil
+	}
+	version, err := queryFirmware(ctx, entryAddr(g.ServiceEntry))
+	if err != nil {
+		return "", err
+	}
+	g.firmware.version = version
+	return version, nil
+}
+
+func queryFirmware(ctx context.Context, ip net.IP) (string, error) {
+	u := fmt.Sprintf("http://%s/setup/eureka_info?params=version,build_info", net.JoinHostPort(ip.String(), strconv.Itoa(localAPIPort)))
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("homecast: setup api returned %s", resp.Status)
+	}
+
+	var info struct {
+		BuildVersion      string `json:"build_version"`
+		CastBuildRevision string `json:"cast_build_revision"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", err
+	}
+	if info.CastBuildRevision != "" {
+		return info.CastBuildRevision, nil
+	}
+	return info.BuildVersion, nil
+}
+
+// require returns ErrUnsupportedByDevice if the device firmware is older than feature needs.
+// Devices of unknown firmware are not refused.
+func (g *CastDevice) require(ctx context.Context, feature string) error {
+	registryMu.RLock()
+	min, ok := minFirmware[feature]
+	registryMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	version, err := g.FirmwareVersion(ctx)
+	if err != nil || version == "" {
+		g.logf("[INFO] Firmware unknown, assume %s is supported: %v", feature, err)
+		return nil
+	}
+	if compareVersion(version, min) < 0 {
+		return fmt.Errorf("%w: %s needs firmware %s, device has %s", ErrUnsupportedByDevice, feature, min, version)
+	}
+	return nil
+}
+
+// compareVersion compares dotted numeric versions
+func compareVersion(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
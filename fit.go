@@ -0,0 +1,38 @@
+package homecast
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// charSpeechTime is estimated time to speak a character at normal speed
+	charSpeechTime = 70 * time.Millisecond
+	// maxSpeakingRate is the fastest rate WithFitDuration speeds speech up to, to keep it intelligible
+	maxSpeakingRate = 1.5
+)
+
+// WithFitDuration makes Speak speed up speech so that it ends within d, such as before a timer goes off.
+// The rate is limited to keep speech intelligible, and it takes effect with TTS providers
+// implementing RateSynthesizer.
+func WithFitDuration(d time.Duration) PlayOption {
+	return func(o *playOptions) {
+		o.fit = d
+	}
+}
+
+// speakingRate returns rate to speak text at so that it fits in duration given by WithFitDuration
+func (o *playOptions) speakingRate(text string) float64 {
+	if o.fit <= 0 {
+		return 1
+	}
+	estimated := time.Duration(utf8.RuneCountInString(text)) * charSpeechTime
+	rate := float64(estimated) / float64(o.fit)
+	if rate < 1 {
+		return 1
+	}
+	if rate > maxSpeakingRate {
+		return maxSpeakingRate
+	}
+	return rate
+}
@@ -0,0 +1,210 @@
+package homecast
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrNothingToRepeat is returned by Repeat when nothing has been spoken
+var ErrNothingToRepeat = errors.New("homecast: nothing to repeat")
+
+// historySize is the number of announcements kept per device
+const historySize = 50
+
+// Announcement is a record of text spoken on cast device
+type Announcement struct {
+	Text string
+	Lang string
+	Time time.Time
+	// Duration is time until playback finished. It is zero while playing.
+	Duration time.Duration
+	// Err is non-nil when the announcement failed
+	Err error
+}
+
+type history struct {
+	mu      sync.Mutex
+	seq     uint64
+	entries []historyEntry
+	// f is the file finished announcements are appended to, if persisted
+	f *os.File
+}
+
+type historyEntry struct {
+	seq uint64
+	Announcement
+}
+
+// add records announcement and returns its sequence number to update it later
+func (h *history) add(a Announcement) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	if len(h.entries) == historySize {
+		copy(h.entries, h.entries[1:])
+		h.entries = h.entries[:historySize-1]
+	}
+	h.entries = append(h.entries, historyEntry{h.seq, a})
+	return h.seq
+}
+
+// update applies f to the announcement of seq if it is still kept, and persists it as finished
+func (h *history) update(seq uint64, f func(*Announcement)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := range h.entries {
+		if h.entries[i].seq == seq {
+			f(&h.entries[i].Announcement)
+			return h.persist(h.entries[i].Announcement)
+		}
+	}
+	return nil
+}
+
+// historyRecord is an announcement persisted as a JSON line
+type historyRecord struct {
+	Text     string        `json:"text"`
+	Lang     string        `json:"lang"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Err      string        `json:"error,omitempty"`
+}
+
+func (h *history) persist(a Announcement) error {
+	if h.f == nil {
+		return nil
+	}
+	return writeAnnouncement(h.f, a)
+}
+
+func writeAnnouncement(w io.Writer, a Announcement) error {
+	r := historyRecord{Text: a.Text, Lang: a.Lang, Time: a.Time, Duration: a.Duration}
+	if a.Err != nil {
+		r.Err = a.Err.Error()
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// open loads announcements persisted at path, and appends finished ones to it from now on.
+// The file is compacted to the announcements kept.
+func (h *history) open(path string) error {
+	var loaded []Announcement
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var r historyRecord
+			// A torn last line is expected after a crash
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				continue
+			}
+			a := Announcement{Text: r.Text, Lang: r.Lang, Time: r.Time, Duration: r.Duration}
+			if r.Err != "" {
+				a.Err = errors.New(r.Err)
+			}
+			loaded = append(loaded, a)
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if len(loaded) > historySize {
+		loaded = loaded[len(loaded)-historySize:]
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	for _, a := range loaded {
+		if err := writeAnnouncement(f, a); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.f != nil {
+		h.f.Close()
+	}
+	if h.f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return err
+	}
+
+	// Announcements made before opening are kept after the loaded ones
+	entries := make([]historyEntry, 0, len(loaded)+len(h.entries))
+	for _, a := range loaded {
+		h.seq++
+		entries = append(entries, historyEntry{h.seq, a})
+	}
+	entries = append(entries, h.entries...)
+	if len(entries) > historySize {
+		entries = entries[len(entries)-historySize:]
+	}
+	h.entries = entries
+	return nil
+}
+
+func (h *history) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.f != nil {
+		h.f.Close()
+		h.f = nil
+	}
+}
+
+// SetHistoryFile persists announcements of cast device to file at path, so that History and Repeat
+// survive restarts. Announcements already in the file are loaded, and finished ones are appended to it.
+// Default is to keep them only in memory.
+func (g *CastDevice) SetHistoryFile(path string) error {
+	return g.history.open(path)
+}
+
+// History returns recent announcements on cast device, oldest first
+func (g *CastDevice) History() []Announcement {
+	g.history.mu.Lock()
+	defer g.history.mu.Unlock()
+	list := make([]Announcement, len(g.history.entries))
+	for i, e := range g.history.entries {
+		list[i] = e.Announcement
+	}
+	return list
+}
+
+// Repeat speaks the last announcement on cast device again, for listeners who missed it
+func (g *CastDevice) Repeat(ctx context.Context, opts ...PlayOption) error {
+	g.history.mu.Lock()
+	n := len(g.history.entries)
+	var last Announcement
+	if n > 0 {
+		last = g.history.entries[n-1].Announcement
+	}
+	g.history.mu.Unlock()
+
+	if n == 0 {
+		return ErrNothingToRepeat
+	}
+	return g.Speak(ctx, last.Text, last.Lang, opts...)
+}
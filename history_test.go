@@ -0,0 +1,77 @@
+package homecast
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	at := time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC)
+
+	var h history
+	if err := h.open(path); err != nil {
+		t.Fatal(err)
+	}
+	ok := h.add(Announcement{Text: "good morning", Lang: "en", Time: at})
+	failed := h.add(Announcement{Text: "bad", Lang: "en", Time: at})
+	h.add(Announcement{Text: "still playing", Lang: "en", Time: at})
+	if err := h.update(ok, func(a *Announcement) { a.Duration = time.Second }); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.update(failed, func(a *Announcement) { a.Err = errors.New("device offline") }); err != nil {
+		t.Fatal(err)
+	}
+	h.close()
+
+	// Only finished announcements are restored
+	var reopened history
+	if err := reopened.open(path); err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.close()
+	if len(reopened.entries) != 2 {
+		t.Fatalf("entries = %v, want 2", reopened.entries)
+	}
+	if a := reopened.entries[0].Announcement; a.Text != "good morning" || a.Duration != time.Second || !a.Time.Equal(at) || a.Err != nil {
+		t.Errorf("entries[0] = %+v", a)
+	}
+	if a := reopened.entries[1].Announcement; a.Text != "bad" || a.Err == nil || a.Err.Error() != "device offline" {
+		t.Errorf("entries[1] = %+v", a)
+	}
+}
+
+func TestHistoryOpenCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	var h history
+	if err := h.open(path); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < historySize+10; i++ {
+		seq := h.add(Announcement{Text: "text"})
+		if err := h.update(seq, func(*Announcement) {}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.close()
+
+	// torn last line after a crash
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"text":"torn`)
+	f.Close()
+
+	var reopened history
+	if err := reopened.open(path); err != nil {
+		t.Fatal(err)
+	}
+	reopened.close()
+	if len(reopened.entries) != historySize {
+		t.Errorf("entries = %d, want %d", len(reopened.entries), historySize)
+	}
+}
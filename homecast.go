@@ -2,112 +2,499 @@ package homecast
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"github.com/barnybug/go-cast"
-	"github.com/barnybug/go-cast/controllers"
-	castnet "github.com/barnybug/go-cast/net"
 	"github.com/micro/mdns"
 )
 
+// DryRun makes Play and Speak only validate and log the call instead of playing:
+// the device is reachable, the text is synthesized, and the media url responds.
+var DryRun bool
+
 const (
 	googleCastServiceName = "_googlecast._tcp"
-	googleHomeModelInfo   = "md=Google Home"
+
+	// queuePreloadTime is seconds before the end of a queue item to start loading the next one
+	queuePreloadTime = 10
+
+	// connectQueueSize is how many discovered devices wait for a free slot of the pool to connect
+	connectQueueSize = 64
 )
 
 // CastDevice is cast-able device contains cast client
 type CastDevice struct {
 	*mdns.ServiceEntry
-	client *cast.Client
+	client   *cast.Client
+	history  history
+	firmware firmware
+	txt      CastTXT
+
+	logger      Logger
+	dialTimeout time.Duration
+	// connMu serializes reconnects
+	connMu sync.Mutex
+	// clientMu guards client and clientGen, which are replaced on reconnect
+	clientMu  sync.RWMutex
+	clientGen uint64
+	queueMu   sync.Mutex
+	loadedMu  sync.Mutex
+	loaded    []string
+
+	events eventHub
+	// ctx is done when cast device is closed, to stop watching media loaded by Play
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// settingsMu guards settings, which are changed while announcements are playing, such as on config reload
+	settingsMu sync.RWMutex
+	settings   deviceSettings
+}
+
+// deviceSettings are settings of cast device changed by its setters
+type deviceSettings struct {
+	maxVolume     float64
+	volPolicy     VolumePolicy
+	volSchedule   VolumeSchedule
+	tts           TTSProvider
+	noSplit       bool
+	lang          string
+	autoReconnect bool
+	takeover      TakeoverPolicy
+	takeoverDelay time.Duration
+	extractor     Extractor
+	clock         Clock
+}
+
+// currentSettings returns a copy of settings of cast device
+func (g *CastDevice) currentSettings() deviceSettings {
+	g.settingsMu.RLock()
+	defer g.settingsMu.RUnlock()
+	return g.settings
+}
+
+// configure changes settings of cast device by f
+func (g *CastDevice) configure(f func(s *deviceSettings)) {
+	g.settingsMu.Lock()
+	defer g.settingsMu.Unlock()
+	f(&g.settings)
 }
 
 // Connect connects required services to cast
 func (g *CastDevice) Connect(ctx context.Context) error {
-	return g.client.Connect(ctx)
+	return g.castClient().Connect(ctx)
 }
 
 // Close calls client's close func
 func (g *CastDevice) Close() {
-	g.client.Close()
+	g.cancel()
+	g.castClient().Close()
+	g.history.close()
+}
+
+// castClient returns current client of cast device
+func (g *CastDevice) castClient() *cast.Client {
+	g.clientMu.RLock()
+	defer g.clientMu.RUnlock()
+	return g.client
+}
+
+// SetExtractor sets the extractor which resolves urls given to Play into direct media urls
+func (g *CastDevice) SetExtractor(e Extractor) {
+	g.configure(func(s *deviceSettings) { s.extractor = e })
 }
 
 // Speak speaks given text on cast device
-func (g *CastDevice) Speak(ctx context.Context, text, lang string) error {
-	url, err := tts(text, lang)
+func (g *CastDevice) Speak(ctx context.Context, text, lang string, opts ...PlayOption) (err error) {
+	defer g.audit(ctx, "speak", text, &err)
+	return g.speak(ctx, []Segment{{Text: text, Lang: lang}}, "", 0, opts)
+}
+
+// speak speaks segments in a row, or ssml if not empty whose text is the only segment.
+// When segments are spoken as chunks, pause is inserted between them.
+func (g *CastDevice) speak(ctx context.Context, segments []Segment, ssml string, pause time.Duration, opts []PlayOption) error {
+	if len(segments) == 0 {
+		return nil
+	}
+	text, lang := segments[0].Text, segments[0].Lang
+	for _, seg := range segments[1:] {
+		text = join(text, seg.Text)
+	}
+	started := g.clk().Now()
+	seq := g.history.add(Announcement{Text: text, Lang: lang, Time: started})
+	record := func(s Session) {
+		err := g.history.update(seq, func(a *Announcement) {
+			a.Duration = g.clk().Now().Sub(started)
+			a.Err = s.Err
+		})
+		if err != nil {
+			g.logf("[ERROR] Failed to persist history: %v", err)
+		}
+	}
+
+	// Synthesized speech is mp3; skip sniffing unless overridden
+	opts = append([]PlayOption{WithContentType(defaultContentType)}, opts...)
+	opts = append(opts, withHook(record), withoutAudit())
+	if newPlayOptions(opts).caption {
+		opts = append(opts, WithTitle(text))
+	}
+	if err := g.applyVolume(ctx, newPlayOptions(opts)); err != nil {
+		record(Session{Err: err})
+		return err
+	}
+
+	o := newPlayOptions(opts)
+	p, err := g.ttsFor(o)
 	if err != nil {
+		record(Session{Err: err})
 		return err
 	}
-	return g.Play(ctx, url)
+
+	if max := maxTextLength(p); len(segments) > 1 || ssml == "" && max > 0 && utf8.RuneCountInString(text) > max {
+		var chunks []Segment
+		for _, seg := range segments {
+			length := utf8.RuneCountInString(seg.Text)
+			if max <= 0 || length <= max {
+				chunks = append(chunks, seg)
+				continue
+			}
+			if g.currentSettings().noSplit {
+				err := &TextTooLongError{Length: length, Max: max}
+				record(Session{Err: err})
+				return err
+			}
+			chunks = append(chunks, chunkSegments(SplitSentences(seg.Text, max), seg.Lang)...)
+		}
+		return g.speakChunks(ctx, chunks, pause, o)
+	}
+
+	url, err := g.synthesizeVoice(ctx, p, text, ssml, lang, o.voiceFor(text))
+	if err != nil {
+		record(Session{Err: err})
+		return err
+	}
+	return g.Play(ctx, url, opts...)
 }
 
 // Play plays media contents on cast device
-func (g *CastDevice) Play(ctx context.Context, url *url.URL) error {
-	conn := castnet.NewConnection()
-	if err := conn.Connect(ctx, g.AddrV4, g.Port); err != nil {
+func (g *CastDevice) Play(ctx context.Context, url *url.URL, opts ...PlayOption) (err error) {
+	o := newPlayOptions(opts)
+	if !o.noAudit {
+		defer g.audit(ctx, "play", url.String(), &err)
+	}
+
+	if DryRun || o.dryRun {
+		return g.dryRun(ctx, url, o)
+	}
+	url, err = g.resolve(ctx, url, o)
+	if err != nil {
+		return err
+	}
+	if url, err = o.process(ctx, g, url); err != nil {
 		return err
 	}
-	defer conn.Close()
 
-	status, err := g.client.Receiver().LaunchApp(ctx, cast.AppMedia)
+	mediaItem := mediaData{
+		ContentId:   url.String(),
+		ContentType: o.contentType,
+		StreamType:  o.streamType,
+		Metadata:    o.mediaMetadata(),
+	}
+	if mediaItem.StreamType == "" {
+		mediaItem.StreamType = StreamBuffered
+	}
+
+	if mediaItem.ContentType == "" {
+		mediaItem.ContentType = sniffContentType(ctx, url)
+	}
+	if o.preflight {
+		if err := preflight(ctx, url); err != nil {
+			o.complete(o.session(g, mediaItem.ContentId, err))
+			return err
+		}
+	}
+
+	s, err := g.loadWithRetry(ctx, mediaItem, o.retries)
 	if err != nil {
+		o.complete(o.session(g, mediaItem.ContentId, err))
 		return err
 	}
-	app := status.GetSessionByAppId(cast.AppMedia)
 
-	cc := controllers.NewConnectionController(conn, g.client.Events, cast.DefaultSender, *app.TransportId)
-	if err := cc.Start(ctx); err != nil {
+	publish(SessionStarted{o.session(g, mediaItem.ContentId, nil)})
+
+	watch := func(ctx context.Context) error {
+		retries := o.retries
+		reasserted := false
+		settings := g.currentSettings()
+		for {
+			err := s.wait(ctx, mediaItem.ContentId)
+			s.Close()
+			if errors.Is(err, ErrSessionTakenOver) && g.supersedes(mediaItem.ContentId) {
+				// Replaced by media this package loaded later
+				err = nil
+			}
+			if errors.Is(err, ErrSessionTakenOver) {
+				publish(SessionTakenOver{o.session(g, mediaItem.ContentId, err)})
+				if settings.takeover == TakeoverReassert && !reasserted {
+					reasserted = true
+					g.logf("[INFO] Session taken over, reassert in %s: content_id=%s", settings.takeoverDelay, mediaItem.ContentId)
+					select {
+					case <-g.clk().After(settings.takeoverDelay):
+					case <-ctx.Done():
+						o.complete(o.session(g, mediaItem.ContentId, ctx.Err()))
+						return ctx.Err()
+					}
+					if s, err = g.loadWithRetry(ctx, mediaItem, retries); err != nil {
+						o.complete(o.session(g, mediaItem.ContentId, err))
+						return err
+					}
+					continue
+				}
+			}
+			if retries <= 0 || !isTemporary(err) {
+				o.complete(o.session(g, mediaItem.ContentId, err))
+				return err
+			}
+			g.logf("[INFO] Retry media after playback error: content_id=%s err=%v", mediaItem.ContentId, err)
+			retries--
+			if s, err = g.loadWithRetry(ctx, mediaItem, retries); err != nil {
+				o.complete(o.session(g, mediaItem.ContentId, err))
+				return err
+			}
+		}
+	}
+	if o.wait {
+		return watch(ctx)
+	}
+	// Live streams don't finish, so they are watched only for callbacks, not for the event bus
+	if o.onComplete == nil && len(o.hooks) == 0 && (!hasSubscribers() || mediaItem.StreamType == StreamLive) {
+		s.Close()
+		return nil
+	}
+	// Keep watching after return until the device is closed; ctx may end with the caller
+	go watch(g.ctx)
+	return nil
+}
+
+// SpeakAndWait speaks text and returns when it has finished playing or ctx is done,
+// so that sequential announcements don't overlap
+func (g *CastDevice) SpeakAndWait(ctx context.Context, text, lang string, opts ...PlayOption) error {
+	return g.Speak(ctx, text, lang, append(opts, WithWait())...)
+}
+
+// dryRun validates Play of url without loading it on cast device.
+// Extractor and pipeline are only logged, not run, as they may be slow or have side effects.
+func (g *CastDevice) dryRun(ctx context.Context, url *url.URL, o playOptions) error {
+	if o.pipeline != "" {
+		if _, ok := LookupPipeline(o.pipeline); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownPipeline, o.pipeline)
+		}
+	}
+	err := g.withReconnect(ctx, func() error {
+		_, err := g.castClient().Receiver().GetStatus(ctx)
+		return err
+	})
+	if err != nil {
 		return err
 	}
-	media := controllers.NewMediaController(conn, g.client.Events, cast.DefaultSender, *app.TransportId)
-	if err := media.Start(ctx); err != nil {
+	extractor, err := g.extractorFor(o)
+	if err != nil {
 		return err
 	}
+	if extractor == nil && o.pipeline == "" {
+		// Otherwise url is not what the device would fetch
+		if _, err := probeURL(ctx, url); err != nil {
+			return err
+		}
+	}
+	g.logf("[INFO] Dry run: skip loading media: device=%s url=%s extractor=%T pipeline=%s", g.Name, url, extractor, o.pipeline)
+	return nil
+}
 
-	mediaItem := controllers.MediaItem{
-		ContentId:   url.String(),
-		ContentType: "audio/mp3",
-		StreamType:  "BUFFERED",
+// loadWithRetry loads media item on launched media receiver, retrying transient failures.
+// Returned session must be closed by caller.
+func (g *CastDevice) loadWithRetry(ctx context.Context, mediaItem mediaData, retries int) (*mediaSession, error) {
+	for attempt := 1; ; attempt++ {
+		s, err := g.launchMedia(ctx)
+		if err != nil {
+			return nil, loadError(err)
+		}
+
+		g.logf("[INFO] Load media: content_id=%s", mediaItem.ContentId)
+		err = s.load(ctx, mediaItem)
+		if err == nil {
+			return s, nil
+		}
+		s.Close()
+		if attempt > retries || !isTemporary(err) {
+			return nil, loadError(err)
+		}
+
+		g.logf("[INFO] Retry loading media: content_id=%s attempt=%d err=%v", mediaItem.ContentId, attempt, err)
+		select {
+		case <-g.clk().After(time.Duration(attempt) * time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// loadError wraps failure of loading media with ErrMediaLoadFailed, except cancellation by caller
+func loadError(err error) error {
+	if isContextError(err) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrMediaLoadFailed, err)
+}
+
+// isContextError reports whether err is cancellation or deadline of context
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Loop plays media contents n times in a row on cast device.
+// When n is zero or less, it repeats the contents until stopped.
+// Each repetition is preloaded while the previous one is playing so that
+// short clips such as white noise loop without gaps.
+func (g *CastDevice) Loop(ctx context.Context, url *url.URL, n int) (err error) {
+	defer g.audit(ctx, "loop", url.String(), &err)
+	url, err = g.resolve(ctx, url, playOptions{})
+	if err != nil {
+		return err
+	}
+
+	s, err := g.launchMedia(ctx)
+	if err != nil {
+		return err
 	}
+	defer s.Close()
 
-	log.Printf("[INFO] Load media: content_id=%s", mediaItem.ContentId)
-	_, err = media.LoadMedia(ctx, mediaItem, 0, true, nil)
+	item := newQueueItem(url, playOptions{})
+	item.PreloadTime = queuePreloadTime
 
-	return err
+	if n <= 0 {
+		// REPEAT_SINGLE reloads the clip after it ends, which leaves an audible gap.
+		// Repeating two copies lets the receiver preload the next one instead.
+		g.logf("[INFO] Loop media: content_id=%s", item.Media.ContentId)
+		return s.queueLoad(ctx, []queueItem{item, item}, RepeatAll)
+	}
+
+	items := make([]queueItem, n)
+	for i := range items {
+		items[i] = item
+	}
+	g.logf("[INFO] Loop media: content_id=%s times=%d", item.Media.ContentId, n)
+	return s.queueLoad(ctx, items, RepeatOff)
 }
 
-// LookupAndConnect retrieves cast-able google home devices
-func LookupAndConnect(ctx context.Context) []*CastDevice {
+// ModelFilter decides which discovered devices LookupAndConnect connects to by their TXT record.
+// Default accepts every cast device.
+var ModelFilter = func(txt CastTXT) bool {
+	return true
+}
+
+// GoogleHomeOnly is a ModelFilter which accepts Google Home family speakers and speaker groups only,
+// such as Google Home, Google Home Mini and Google Nest Mini
+func GoogleHomeOnly(txt CastTXT) bool {
+	if txt.IsGroup() {
+		return true
+	}
+	model := txt.Model()
+	return strings.HasPrefix(model, "Google Home") || strings.HasPrefix(model, "Google Nest")
+}
+
+// LookupAndConnect retrieves cast-able devices accepted by ModelFilter.
+// Devices are connected to concurrently as they are found, bounded by the pool given by WithPool.
+// If discovery or connecting to some devices fails, *LookupError is returned along with devices connected.
+// No devices with nil error means none was found.
+func LookupAndConnect(ctx context.Context, opts ...DiscoverOption) ([]*CastDevice, error) {
+	o := newDiscoverOptions(opts)
 	entriesCh := make(chan *mdns.ServiceEntry, 4)
 
+	stats := DiscoveryStats{Started: time.Now()}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
 	results := make([]*CastDevice, 0, 4)
+	var connectErrs []*ConnectError
+	connect := func(entry *mdns.ServiceEntry) {
+		defer wg.Done()
+		device := newCastDevice(entry, o.deviceOptions())
+		err := device.Connect(ctx)
+		mu.Lock()
+		if err != nil {
+			stats.Failures++
+			connectErrs = append(connectErrs, &ConnectError{Entry: entry, Err: err})
+		} else {
+			stats.Connected++
+			results = append(results, device)
+		}
+		mu.Unlock()
+		if err != nil {
+			logf(o.Logger, "[ERROR] Failed to connect: %s", err)
+			publish(ErrorEvent{Err: err})
+			return
+		}
+		publish(DeviceFound{device})
+	}
+
+	// Entries wait in the queue for a free slot of the pool, so that responses are read while the pool is full
+	queue := make(chan *mdns.ServiceEntry, connectQueueSize)
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		for entry := range queue {
+			entry := entry
+			wg.Add(1)
+			o.Pool.Go(func() { connect(entry) })
+		}
+	}()
+
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
+		defer close(queue)
+		seen := map[string]bool{}
 		for entry := range entriesCh {
-			log.Printf("[INFO] ServiceEntry detected: [%s:%d]%s", entry.AddrV4, entry.Port, entry.Name)
-			for _, field := range entry.InfoFields {
-				if strings.HasPrefix(field, googleHomeModelInfo) {
-					client := cast.NewClient(entry.AddrV4, entry.Port)
-					if err := client.Connect(ctx); err != nil {
-						log.Printf("[ERROR] Failed to connect: %s", err)
-						continue
-					}
-					results = append(results, &CastDevice{entry, client})
-				}
+			mu.Lock()
+			stats.Responses++
+			mu.Unlock()
+			logf(o.Logger, "[INFO] ServiceEntry detected: [%s:%d]%s", entryAddr(entry), entry.Port, entry.Name)
+			txt := ParseCastTXT(entry.InfoFields)
+			if !ModelFilter(txt) {
+				logf(o.Logger, "[INFO] Skip device due to model filter: model=%s", txt.Model())
+				continue
 			}
+			// Devices answer more than once
+			key := deviceKey(entry)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			// Blocks while the queue is full
+			queue <- entry
 		}
 	}()
 
-	mdns.Lookup(googleCastServiceName, entriesCh)
+	discoveryErrs := discover(ctx, o, entriesCh)
 	close(entriesCh)
+	<-done
+	<-dispatched
+	wg.Wait()
 
-	return results
-}
+	stats.Duration = time.Since(stats.Started)
+	recordDiscoveryStats(stats)
+	publish(DiscoveryFinished{stats})
+	logf(o.Logger, "[INFO] Discovery finished: duration=%s responses=%d connected=%d failures=%d",
+		stats.Duration, stats.Responses, stats.Connected, stats.Failures)
 
-// tts provides text-to-speech sound url.
-// NOTE: it seems to be unofficial.
-func tts(text, lang string) (*url.URL, error) {
-	base := "https://translate.google.com/translate_tts?client=tw-ob&ie=UTF-8&q=%s&tl=%s"
-	return url.Parse(fmt.Sprintf(base, url.QueryEscape(text), url.QueryEscape(lang)))
+	if len(discoveryErrs) > 0 || len(connectErrs) > 0 {
+		return results, &LookupError{Discovery: discoveryErrs, Connect: connectErrs}
+	}
+	return results, nil
 }
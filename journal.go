@@ -0,0 +1,187 @@
+package homecast
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// journalDedupeWindow is how long keys of delivered entries are remembered across restarts
+const journalDedupeWindow = 24 * time.Hour
+
+// JournalEntry is an announcement request recorded in Journal
+type JournalEntry struct {
+	// Key identifies the request. Requests with known key are ignored.
+	Key    string    `json:"key"`
+	Device string    `json:"device,omitempty"`
+	Text   string    `json:"text"`
+	Lang   string    `json:"lang"`
+	Time   time.Time `json:"time"`
+}
+
+type journalRecord struct {
+	Op string `json:"op"`
+	JournalEntry
+}
+
+// Journal is a write-ahead log of announcement requests.
+// Requests are appended before being delivered and marked done after, so that
+// undelivered ones can be replayed after a crash.
+type Journal struct {
+	mu      sync.Mutex
+	f       *os.File
+	pending []JournalEntry
+	done    map[string]time.Time
+}
+
+// OpenJournal opens journal file at path, creating it if not exists.
+// The file is compacted to pending entries and recently delivered keys.
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{done: map[string]time.Time{}}
+	if err := j.load(path); err != nil {
+		return nil, err
+	}
+	if err := j.compact(path); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *Journal) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r journalRecord
+		// A torn last line is expected after a crash
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		switch r.Op {
+		case "append":
+			j.pending = append(j.pending, r.JournalEntry)
+		case "done":
+			j.removePending(r.Key)
+			j.done[r.Key] = r.Time
+		}
+	}
+	return scanner.Err()
+}
+
+func (j *Journal) compact(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for key, t := range j.done {
+		if time.Since(t) > journalDedupeWindow {
+			delete(j.done, key)
+			continue
+		}
+		if err := enc.Encode(journalRecord{"done", JournalEntry{Key: key, Time: t}}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	for _, e := range j.pending {
+		if err := enc.Encode(journalRecord{"append", e}); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	j.f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	return err
+}
+
+// ErrEmptyJournalKey is returned when an entry without key is appended to Journal
+var ErrEmptyJournalKey = errors.New("homecast: journal entry has empty key")
+
+// Append records e before it is delivered.
+// It returns false without recording when an entry with the same key is pending or was delivered.
+func (j *Journal) Append(e JournalEntry) (bool, error) {
+	if e.Key == "" {
+		return false, ErrEmptyJournalKey
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.done[e.Key]; ok {
+		return false, nil
+	}
+	for _, p := range j.pending {
+		if p.Key == e.Key {
+			return false, nil
+		}
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if err := j.write(journalRecord{"append", e}); err != nil {
+		return false, err
+	}
+	j.pending = append(j.pending, e)
+	return true, nil
+}
+
+// Done marks the entry of key as delivered
+func (j *Journal) Done(key string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	if err := j.write(journalRecord{"done", JournalEntry{Key: key, Time: now}}); err != nil {
+		return err
+	}
+	j.removePending(key)
+	j.done[key] = now
+	return nil
+}
+
+// Pending returns entries not yet delivered, oldest first
+func (j *Journal) Pending() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]JournalEntry(nil), j.pending...)
+}
+
+// Close closes journal file
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+func (j *Journal) write(r journalRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+func (j *Journal) removePending(key string) {
+	for i, p := range j.pending {
+		if p.Key == key {
+			j.pending = append(j.pending[:i], j.pending[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package homecast
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenJournalReplay(t *testing.T) {
+	recent := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	old := time.Now().Add(-2 * journalDedupeWindow).UTC().Truncate(time.Second)
+	record := func(op, key string, at time.Time) string {
+		b, _ := json.Marshal(journalRecord{op, JournalEntry{Key: key, Text: "text of " + key, Time: at}})
+		return string(b)
+	}
+
+	tests := []struct {
+		name        string
+		lines       []string
+		wantPending []string
+		wantDone    []string
+	}{
+		{"empty", nil, nil, nil},
+		{"pending", []string{record("append", "a", recent), record("append", "b", recent)}, []string{"a", "b"}, nil},
+		{"delivered", []string{record("append", "a", recent), record("done", "a", recent)}, nil, []string{"a"}},
+		{"torn last line", []string{record("append", "a", recent), `{"op":"done","key":"a"`}, []string{"a"}, nil},
+		{"expired key forgotten", []string{record("append", "a", old), record("done", "a", old)}, nil, nil},
+		{"unknown op ignored", []string{record("skip", "a", recent)}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "journal")
+			if tt.lines != nil {
+				if err := os.WriteFile(path, []byte(strings.Join(tt.lines, "\n")+"\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			j, err := OpenJournal(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := journalKeys(j.Pending()); !reflect.DeepEqual(got, tt.wantPending) {
+				t.Errorf("pending = %v, want %v", got, tt.wantPending)
+			}
+			var done []string
+			for key := range j.done {
+				done = append(done, key)
+			}
+			if !reflect.DeepEqual(done, tt.wantDone) {
+				t.Errorf("done = %v, want %v", done, tt.wantDone)
+			}
+
+			// Compacted file holds the same state
+			j.Close()
+			reopened, err := OpenJournal(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reopened.Close()
+			if got := journalKeys(reopened.Pending()); !reflect.DeepEqual(got, tt.wantPending) {
+				t.Errorf("pending after compaction = %v, want %v", got, tt.wantPending)
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lines := strings.Count(string(b), "\n"); lines != len(tt.wantPending)+len(tt.wantDone) {
+				t.Errorf("compacted file has %d records, want %d:\n%s", lines, len(tt.wantPending)+len(tt.wantDone), b)
+			}
+		})
+	}
+}
+
+func TestJournalAppendDone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, err := OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []struct {
+		op   string
+		key  string
+		want bool
+	}{
+		{"append", "a", true},
+		{"append", "a", false},
+		{"append", "b", true},
+		{"done", "a", true},
+		{"append", "a", false},
+	}
+	for _, s := range steps {
+		if s.op == "done" {
+			if err := j.Done(s.key); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		ok, err := j.Append(JournalEntry{Key: s.key, Text: s.key})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != s.want {
+			t.Errorf("Append(%s) = %v, want %v", s.key, ok, s.want)
+		}
+	}
+	j.Close()
+
+	// Undelivered entry is replayed, and delivered key is still deduplicated
+	j, err = OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if got := journalKeys(j.Pending()); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("pending = %v, want [b]", got)
+	}
+	if ok, _ := j.Append(JournalEntry{Key: "a"}); ok {
+		t.Error("delivered key was appended again after reopen")
+	}
+}
+
+func TestJournalAppendEmptyKey(t *testing.T) {
+	j, err := OpenJournal(filepath.Join(t.TempDir(), "journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if ok, err := j.Append(JournalEntry{Text: "text"}); ok || !errors.Is(err, ErrEmptyJournalKey) {
+		t.Errorf("Append() = %v, %v, want false, %v", ok, err, ErrEmptyJournalKey)
+	}
+	if got := j.Pending(); len(got) != 0 {
+		t.Errorf("pending = %v, want none", got)
+	}
+}
+
+func journalKeys(entries []JournalEntry) []string {
+	var keys []string
+	for _, e := range entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
@@ -0,0 +1,106 @@
+package homecast
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	lastFMEndpoint = "https://ws.audioscrobbler.com/2.0/"
+	// lastFMMinListen is how long a track must be listened to be scrobbled
+	lastFMMinListen = 30 * time.Second
+)
+
+// LastFM is Scrobbler submitting tracks to Last.fm.
+// Tracks without artist or title are skipped.
+type LastFM struct {
+	APIKey    string
+	APISecret string
+	// SessionKey is session key of the user obtained by Last.fm authentication
+	SessionKey string
+}
+
+// NowPlaying updates now playing track of the user
+func (l *LastFM) NowPlaying(ctx context.Context, t Track) error {
+	if t.Artist == "" || t.Title == "" {
+		return nil
+	}
+	return l.call(ctx, "track.updateNowPlaying", l.params(t))
+}
+
+// Scrobble adds the track to listening history of the user
+func (l *LastFM) Scrobble(ctx context.Context, t Track) error {
+	if t.Artist == "" || t.Title == "" || t.Listened < lastFMMinListen {
+		return nil
+	}
+	params := l.params(t)
+	params.Set("timestamp", strconv.FormatInt(t.Started.Unix(), 10))
+	return l.call(ctx, "track.scrobble", params)
+}
+
+func (l *LastFM) params(t Track) url.Values {
+	params := url.Values{}
+	params.Set("artist", t.Artist)
+	params.Set("track", t.Title)
+	if t.Album != "" {
+		params.Set("album", t.Album)
+	}
+	return params
+}
+
+func (l *LastFM) call(ctx context.Context, method string, params url.Values) error {
+	params.Set("method", method)
+	params.Set("api_key", l.APIKey)
+	params.Set("sk", l.SessionKey)
+	params.Set("api_sig", l.sign(params))
+	params.Set("format", "json")
+
+	req, err := http.NewRequest(http.MethodPost, lastFMEndpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Error   int    `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("homecast: last.fm returned %s", resp.Status)
+	}
+	if result.Error != 0 {
+		return fmt.Errorf("homecast: last.fm error %d: %s", result.Error, result.Message)
+	}
+	return nil
+}
+
+// sign computes api_sig, md5 of parameters sorted by name concatenated with the secret
+func (l *LastFM) sign(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteString(params.Get(k))
+	}
+	b.WriteString(l.APISecret)
+	sum := md5.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,72 @@
+package homecast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const listenBrainzEndpoint = "https://api.listenbrainz.org/1/submit-listens"
+
+// ListenBrainz is Scrobbler submitting listens to ListenBrainz.
+// Tracks without artist or title are skipped.
+type ListenBrainz struct {
+	// Token is user token found in ListenBrainz profile settings
+	Token string
+}
+
+type listenBrainzListen struct {
+	ListenedAt    int64             `json:"listened_at,omitempty"`
+	TrackMetadata listenBrainzTrack `json:"track_metadata"`
+}
+
+type listenBrainzTrack struct {
+	ArtistName  string `json:"artist_name"`
+	TrackName   string `json:"track_name"`
+	ReleaseName string `json:"release_name,omitempty"`
+}
+
+// NowPlaying submits the track as playing now
+func (l *ListenBrainz) NowPlaying(ctx context.Context, t Track) error {
+	return l.submit(ctx, "playing_now", t, 0)
+}
+
+// Scrobble submits the track as listened
+func (l *ListenBrainz) Scrobble(ctx context.Context, t Track) error {
+	return l.submit(ctx, "single", t, t.Started.Unix())
+}
+
+func (l *ListenBrainz) submit(ctx context.Context, listenType string, t Track, listenedAt int64) error {
+	if t.Artist == "" || t.Title == "" {
+		return nil
+	}
+	body := map[string]interface{}{
+		"listen_type": listenType,
+		"payload": []listenBrainzListen{{
+			ListenedAt:    listenedAt,
+			TrackMetadata: listenBrainzTrack{ArtistName: t.Artist, TrackName: t.Title, ReleaseName: t.Album},
+		}},
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, listenBrainzEndpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Token "+l.Token)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("homecast: listenbrainz returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package homecast
+
+import (
+	"context"
+)
+
+// ServeAndPlay serves local audio file such as MP3 or WAV over HTTP on local network and plays it on cast device.
+// The server is started for this playback only, configured like DefaultMediaServer, and is shut down
+// when playback ends.
+func (g *CastDevice) ServeAndPlay(ctx context.Context, name string, opts ...PlayOption) error {
+	server := &MediaServer{
+		Host:    DefaultMediaServer.Host,
+		MinPort: DefaultMediaServer.MinPort,
+		MaxPort: DefaultMediaServer.MaxPort,
+	}
+	u, err := server.ServeFile(name)
+	if err != nil {
+		return err
+	}
+
+	shutdown := withHook(func(Session) {
+		server.Close()
+	})
+	if err := g.Play(ctx, u, append(opts[:len(opts):len(opts)], shutdown)...); err != nil {
+		server.Close()
+		return err
+	}
+	if DryRun || newPlayOptions(opts).dryRun {
+		server.Close()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package homecast
+
+import (
+	"context"
+	"net"
+
+	"github.com/micro/mdns"
+)
+
+// DeviceInfo is a cast device found by Lookup, not connected yet
+type DeviceInfo struct {
+	Entry        *mdns.ServiceEntry
+	FriendlyName string
+	Addr         net.IP
+	Port         int
+	TXT          CastTXT
+
+	opts []Option
+}
+
+// Lookup discovers cast devices accepted by ModelFilter without connecting to them, such as to list
+// them in a picker. Call Connect of the chosen ones.
+// If discovery fails, *LookupError is returned along with devices found.
+func Lookup(ctx context.Context, opts ...DiscoverOption) ([]*DeviceInfo, error) {
+	o := newDiscoverOptions(opts)
+	entriesCh := make(chan *mdns.ServiceEntry, 4)
+
+	seen := map[string]bool{}
+	var infos []*DeviceInfo
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for entry := range entriesCh {
+			txt := ParseCastTXT(entry.InfoFields)
+			if !ModelFilter(txt) {
+				continue
+			}
+			if key := deviceKey(entry); !seen[key] {
+				seen[key] = true
+				infos = append(infos, &DeviceInfo{
+					Entry:        entry,
+					FriendlyName: txt.FriendlyName(),
+					Addr:         entryAddr(entry),
+					Port:         entry.Port,
+					TXT:          txt,
+					opts:         o.deviceOptions(),
+				})
+			}
+		}
+	}()
+
+	errs := discover(ctx, o, entriesCh)
+	close(entriesCh)
+	<-done
+	logf(o.Logger, "[INFO] Lookup finished: found=%d", len(infos))
+
+	if len(errs) > 0 {
+		return infos, &LookupError{Discovery: errs}
+	}
+	return infos, nil
+}
+
+// Connect connects to the device, configured by device options given to Lookup
+func (d *DeviceInfo) Connect(ctx context.Context) (*CastDevice, error) {
+	g := newCastDevice(d.Entry, d.opts)
+	if err := g.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
@@ -0,0 +1,336 @@
+package homecast
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/barnybug/go-cast"
+	"github.com/barnybug/go-cast/api"
+	"github.com/barnybug/go-cast/controllers"
+	castnet "github.com/barnybug/go-cast/net"
+)
+
+const mediaNamespace = "urn:x-cast:com.google.cast.media"
+
+// Errors reported by media receiver. MediaError matches one of them with errors.Is.
+var (
+	// ErrNoMediaSession is returned when nothing is loaded on the media receiver
+	ErrNoMediaSession = errors.New("homecast: no active media session")
+	// ErrAppNotLaunched is returned when the receiver app could not be launched
+	ErrAppNotLaunched = errors.New("homecast: media receiver app not launched")
+	// ErrLoadFailed is returned when the media could not be fetched or is not supported by the device
+	ErrLoadFailed = errors.New("homecast: media load failed")
+	// ErrLoadCancelled is returned when the load was interrupted by another request, i.e. device is busy
+	ErrLoadCancelled = errors.New("homecast: media load cancelled")
+	// ErrInvalidRequest is returned when the receiver rejected the request itself
+	ErrInvalidRequest = errors.New("homecast: invalid request")
+	// ErrInvalidPlayerState is returned when the request does not fit the current player state
+	ErrInvalidPlayerState = errors.New("homecast: invalid player state")
+)
+
+// ErrMediaLoadFailed is returned by Play when media could not be loaded on device for any reason.
+// The cause, such as ErrLoadFailed or ErrAppNotLaunched, is matched too.
+var ErrMediaLoadFailed = errors.New("homecast: failed to load media")
+
+// RepeatMode is the repeat behavior of the queue loaded on media receiver
+type RepeatMode string
+
+// Repeat modes supported by the default media receiver
+const (
+	RepeatOff           RepeatMode = "REPEAT_OFF"
+	RepeatAll           RepeatMode = "REPEAT_ALL"
+	RepeatSingle        RepeatMode = "REPEAT_SINGLE"
+	RepeatAllAndShuffle RepeatMode = "REPEAT_ALL_AND_SHUFFLE"
+)
+
+// mediaSession is a connection to the media receiver app running on device
+type mediaSession struct {
+	conn    *castnet.Connection
+	media   *controllers.MediaController
+	channel *castnet.Channel
+	clock   Clock
+	device  *CastDevice
+	// sessionID is id of the media receiver app session
+	sessionID string
+}
+
+// launchMedia launches the media receiver app and connects to it
+func (g *CastDevice) launchMedia(ctx context.Context) (*mediaSession, error) {
+	return g.openMedia(ctx, true)
+}
+
+// attachMedia connects to the media receiver app already running on device
+func (g *CastDevice) attachMedia(ctx context.Context) (*mediaSession, error) {
+	s, err := g.openMedia(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.media.GetStatus(ctx)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if len(status.Status) == 0 {
+		s.Close()
+		return nil, ErrNoMediaSession
+	}
+	s.media.MediaSessionID = status.Status[0].MediaSessionID
+	return s, nil
+}
+
+func (g *CastDevice) openMedia(ctx context.Context, launch bool) (*mediaSession, error) {
+	var status *controllers.ReceiverStatus
+	err := g.withReconnect(ctx, func() (err error) {
+		if launch {
+			status, err = g.castClient().Receiver().LaunchApp(ctx, cast.AppMedia)
+		} else {
+			status, err = g.castClient().Receiver().GetStatus(ctx)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	app := status.GetSessionByAppId(cast.AppMedia)
+	if app == nil || app.TransportId == nil {
+		if launch {
+			return nil, ErrAppNotLaunched
+		}
+		return nil, ErrNoMediaSession
+	}
+
+	conn := castnet.NewConnection()
+	if err := conn.Connect(ctx, entryAddr(g.ServiceEntry), g.Port); err != nil {
+		return nil, err
+	}
+
+	cc := controllers.NewConnectionController(conn, g.castClient().Events, cast.DefaultSender, *app.TransportId)
+	if err := cc.Start(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	media := controllers.NewMediaController(conn, g.castClient().Events, cast.DefaultSender, *app.TransportId)
+	if err := media.Start(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	s := &mediaSession{
+		conn:    conn,
+		media:   media,
+		channel: conn.NewChannel(cast.DefaultSender, *app.TransportId, mediaNamespace),
+		clock:   g.clk(),
+		device:  g,
+	}
+	if app.SessionID != nil {
+		s.sessionID = *app.SessionID
+	}
+	return s, nil
+}
+
+// MediaError is an error reported by media receiver.
+// It matches ErrPlaybackFailed and the error of its Type, such as ErrLoadFailed, with errors.Is.
+type MediaError struct {
+	// Type is the message type such as LOAD_FAILED, or ERROR for failure during playback
+	Type   string
+	Reason string
+}
+
+func (e *MediaError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("homecast: media error: %s (%s)", e.Type, e.Reason)
+	}
+	return fmt.Sprintf("homecast: media error: %s", e.Type)
+}
+
+// Unwrap returns ErrPlaybackFailed
+func (e *MediaError) Unwrap() error {
+	return ErrPlaybackFailed
+}
+
+// Is reports whether target is the error of e's Type
+func (e *MediaError) Is(target error) bool {
+	return target != nil && mediaErrorTypes[e.Type] == target
+}
+
+// Temporary reports whether retrying may succeed
+func (e *MediaError) Temporary() bool {
+	return e.Type == "LOAD_FAILED" || e.Type == "ERROR"
+}
+
+func isTemporary(err error) bool {
+	var merr *MediaError
+	return errors.As(err, &merr) && merr.Temporary()
+}
+
+// mediaErrorTypes maps response types of media namespace indicating failure to errors
+var mediaErrorTypes = map[string]error{
+	"LOAD_FAILED":          ErrLoadFailed,
+	"LOAD_CANCELLED":       ErrLoadCancelled,
+	"INVALID_PLAYER_STATE": ErrInvalidPlayerState,
+	"INVALID_REQUEST":      ErrInvalidRequest,
+	"ERROR":                ErrPlaybackFailed,
+}
+
+// checkResponse converts an error response from media receiver into MediaError
+func checkResponse(msg *api.CastMessage) error {
+	if msg == nil {
+		return nil
+	}
+	var payload struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(msg.GetPayloadUtf8()), &payload); err != nil {
+		return err
+	}
+	if _, ok := mediaErrorTypes[payload.Type]; ok {
+		return &MediaError{Type: payload.Type, Reason: payload.Reason}
+	}
+	return nil
+}
+
+// mediaData is media information of LOAD and QUEUE_LOAD requests
+type mediaData struct {
+	ContentId   string         `json:"contentId"`
+	StreamType  string         `json:"streamType"`
+	ContentType string         `json:"contentType"`
+	Metadata    *mediaMetadata `json:"metadata,omitempty"`
+}
+
+// Stream types of media given to WithStreamType
+const (
+	// StreamBuffered is media of fixed length such as files. It is the default.
+	StreamBuffered = "BUFFERED"
+	// StreamLive is live media such as internet radio
+	StreamLive = "LIVE"
+)
+
+// defaultContentType is content type of media whose type is unknown, and of synthesized speech
+const defaultContentType = "audio/mp3"
+
+// Metadata types of mediaMetadata
+const (
+	genericMetadata    = 0
+	musicTrackMetadata = 3
+)
+
+// mediaMetadata is GenericMediaMetadata or MusicTrackMediaMetadata, shown by devices with display
+type mediaMetadata struct {
+	MetadataType int    `json:"metadataType"`
+	Title        string `json:"title,omitempty"`
+	Artist       string `json:"artist,omitempty"`
+	AlbumName    string `json:"albumName,omitempty"`
+}
+
+type loadCommand struct {
+	castnet.PayloadHeaders
+	MediaSessionID int       `json:"mediaSessionId,omitempty"`
+	Media          mediaData `json:"media"`
+	CurrentTime    float64   `json:"currentTime"`
+	Autoplay       bool      `json:"autoplay"`
+}
+
+// load loads media and starts playing it
+func (s *mediaSession) load(ctx context.Context, media mediaData) error {
+	return s.loadAt(ctx, media, 0, true)
+}
+
+// loadAt loads media at position in seconds, starting to play it if autoplay is true
+func (s *mediaSession) loadAt(ctx context.Context, media mediaData, position float64, autoplay bool) error {
+	msg, err := s.channel.Request(ctx, &loadCommand{
+		PayloadHeaders: castnet.PayloadHeaders{Type: "LOAD"},
+		Media:          media,
+		CurrentTime:    position,
+		Autoplay:       autoplay,
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(msg); err != nil {
+		return err
+	}
+	s.device.setLoaded(media.ContentId)
+	return nil
+}
+
+// Close closes connection to media receiver
+func (s *mediaSession) Close() {
+	s.conn.Close()
+}
+
+type queueItem struct {
+	Media       mediaData `json:"media"`
+	Autoplay    bool      `json:"autoplay"`
+	PreloadTime float64   `json:"preloadTime,omitempty"`
+}
+
+// newQueueItem returns queue item of u, of content type and stream type set by o or the defaults
+func newQueueItem(u *url.URL, o playOptions) queueItem {
+	item := queueItem{
+		Media: mediaData{
+			ContentId:   u.String(),
+			ContentType: o.contentType,
+			StreamType:  o.streamType,
+		},
+		Autoplay: true,
+	}
+	if item.Media.ContentType == "" {
+		item.Media.ContentType = defaultContentType
+	}
+	if item.Media.StreamType == "" {
+		item.Media.StreamType = StreamBuffered
+	}
+	return item
+}
+
+type queueLoadCommand struct {
+	castnet.PayloadHeaders
+	Items       []queueItem `json:"items"`
+	StartIndex  int         `json:"startIndex"`
+	CurrentTime float64     `json:"currentTime,omitempty"`
+	RepeatMode  RepeatMode  `json:"repeatMode"`
+}
+
+type queueUpdateCommand struct {
+	castnet.PayloadHeaders
+	MediaSessionID int        `json:"mediaSessionId"`
+	RepeatMode     RepeatMode `json:"repeatMode,omitempty"`
+	Jump           int        `json:"jump,omitempty"`
+	CurrentItemID  int        `json:"currentItemId,omitempty"`
+	Shuffle        *bool      `json:"shuffle,omitempty"`
+}
+
+// queueLoad loads items as a queue and starts playing from the first one
+func (s *mediaSession) queueLoad(ctx context.Context, items []queueItem, mode RepeatMode) error {
+	return s.queueLoadAt(ctx, items, 0, 0, mode)
+}
+
+// queueLoadAt loads items as a queue and starts from items[start] at position in seconds
+func (s *mediaSession) queueLoadAt(ctx context.Context, items []queueItem, start int, position float64, mode RepeatMode) error {
+	if err := s.device.require(ctx, "queue"); err != nil {
+		return err
+	}
+	msg, err := s.channel.Request(ctx, &queueLoadCommand{
+		PayloadHeaders: castnet.PayloadHeaders{Type: "QUEUE_LOAD"},
+		Items:          items,
+		StartIndex:     start,
+		CurrentTime:    position,
+		RepeatMode:     mode,
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkResponse(msg); err != nil {
+		return err
+	}
+	contentIDs := make([]string, len(items))
+	for i, item := range items {
+		contentIDs[i] = item.Media.ContentId
+	}
+	s.device.setLoaded(contentIDs...)
+	return nil
+}
@@ -0,0 +1,361 @@
+package homecast
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// mediaTTL is how long media registered on MediaServer is served
+	mediaTTL = 10 * time.Minute
+	// streamReplayMax is the most bytes of a stream kept to serve it again, such as when a device
+	// retries the media or reloads it after takeover
+	streamReplayMax = 16 << 20
+)
+
+// MediaServer serves audio held by this process to cast devices over HTTP on local network
+type MediaServer struct {
+	// Host is IP address or hostname advertised to cast devices, for NAT or multi-homed hosts.
+	// Default is the address of the interface used to reach other hosts.
+	Host string
+	// MinPort and MaxPort limit the port to listen on, such as to allow it through firewall.
+	// Default is a random port.
+	MinPort, MaxPort int
+	// Logger logs start of server and streaming failures. Default logs nothing.
+	Logger Logger
+	// Cache is looked up for audio of cached speech requested by its key but not registered, so that
+	// urls of ServeStable keep working after they expire, and other instances can fetch audio
+	// synthesized by this one with HTTPCache. Default serves only registered media.
+	Cache TTSCache
+	// CacheWritable lets other instances add audio to Cache with HTTPCache. Enable it only on trusted networks.
+	CacheWritable bool
+
+	mu       sync.Mutex
+	listener net.Listener
+	host     string
+	media    map[string]*servedMedia
+}
+
+type servedMedia struct {
+	data        []byte
+	contentType string
+	// path is local file served instead of data
+	path string
+	// reader is streamed to the first GET request instead of data.
+	// Once read to the end, what was read is served as data to later requests.
+	reader io.Reader
+	// streaming is true while reader is streamed, or after it failed to be kept for replay
+	streaming bool
+	// expires is zero for media served until the server is closed
+	expires time.Time
+	// etag is set for media registered by ServeStable, which never changes at its url
+	etag string
+}
+
+// DefaultMediaServer is used by TTS providers and helpers which need to serve audio.
+// It starts listening on first use.
+var DefaultMediaServer = &MediaServer{}
+
+// ServeBytes registers audio data and returns url where cast devices can fetch it
+func (m *MediaServer) ServeBytes(data []byte, contentType string) (*url.URL, error) {
+	return m.register(&servedMedia{data: data, contentType: contentType, expires: time.Now().Add(mediaTTL)}, "")
+}
+
+// ServeStable registers audio data identified by key, such as a hash of cached speech, and returns
+// url which is the same for the same key. It is served with ETag and Cache-Control headers so that
+// cast devices reuse audio fetched before, as long as it is served. data must not change for key.
+func (m *MediaServer) ServeStable(key string, data []byte, contentType string) (*url.URL, error) {
+	media := &servedMedia{data: data, contentType: contentType, expires: time.Now().Add(mediaTTL), etag: `"` + key + `"`}
+	return m.registerID(key, media, "")
+}
+
+// ServeFile registers local audio file, such as MP3 or WAV, and returns url where cast devices can fetch it.
+// The file is served until the server is closed.
+func (m *MediaServer) ServeFile(name string) (*url.URL, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("homecast: %s is a directory", name)
+	}
+	return m.register(&servedMedia{path: name}, filepath.Ext(name))
+}
+
+// ServeReader registers audio stream read from r, such as output of ffmpeg, and returns url where
+// cast devices can fetch it. r is streamed with chunked encoding to the first request.
+// Streams up to 16 MiB are kept once read to the end and served again to later requests until they expire;
+// longer ones are removed once streamed, and requests made while streaming are answered with 410 Gone.
+func (m *MediaServer) ServeReader(r io.Reader, contentType string) (*url.URL, error) {
+	return m.register(&servedMedia{reader: r, contentType: contentType, expires: time.Now().Add(mediaTTL)}, "")
+}
+
+// register adds media to serve with random id and returns its url, ending with ext
+func (m *MediaServer) register(media *servedMedia, ext string) (*url.URL, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	return m.registerID(id, media, ext)
+}
+
+// registerID adds media to serve as id, replacing media of the same id, and returns its url ending with ext
+func (m *MediaServer) registerID(id string, media *servedMedia, ext string) (*url.URL, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.start(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for id, media := range m.media {
+		if !media.expires.IsZero() && now.After(media.expires) {
+			closeReader(media.reader)
+			delete(m.media, id)
+		}
+	}
+
+	m.media[id] = media
+	return &url.URL{Scheme: "http", Host: m.host, Path: "/media/" + id + ext}, nil
+}
+
+// Close stops listening and forgets registered media. The server starts again on next use.
+func (m *MediaServer) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.listener == nil {
+		return nil
+	}
+	err := m.listener.Close()
+	m.listener = nil
+	m.media = nil
+	return err
+}
+
+// start listens on a port within the range, advertising Host or the address of outbound interface.
+// Caller must hold m.mu.
+func (m *MediaServer) start() error {
+	if m.listener != nil {
+		return nil
+	}
+	host := m.Host
+	if host == "" {
+		ip, err := outboundIP()
+		if err != nil {
+			return err
+		}
+		host = ip.String()
+	}
+	l, err := m.listen()
+	if err != nil {
+		return err
+	}
+	m.listener = l
+	m.host = net.JoinHostPort(host, fmt.Sprint(l.Addr().(*net.TCPAddr).Port))
+	m.media = map[string]*servedMedia{}
+
+	logf(m.Logger, "[INFO] Media server started: %s", m.host)
+	go http.Serve(l, http.HandlerFunc(m.serveHTTP))
+	return nil
+}
+
+// listen listens on the first free port between MinPort and MaxPort
+func (m *MediaServer) listen() (net.Listener, error) {
+	if m.MinPort == 0 && m.MaxPort == 0 {
+		return net.Listen("tcp", ":0")
+	}
+	min, max := m.MinPort, m.MaxPort
+	if max == 0 {
+		max = min
+	}
+	if min <= 0 || max < min || max > 65535 {
+		return nil, fmt.Errorf("homecast: invalid media server port range: %d-%d", m.MinPort, m.MaxPort)
+	}
+	var err error
+	for port := min; port <= max; port++ {
+		var l net.Listener
+		if l, err = net.Listen("tcp", fmt.Sprintf(":%d", port)); err == nil {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("homecast: no free port in %d-%d: %w", min, max, err)
+}
+
+func (m *MediaServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/media/")
+	id = strings.TrimSuffix(id, path.Ext(id))
+	if r.Method == http.MethodPut {
+		m.putCached(w, r, id)
+		return
+	}
+	m.mu.Lock()
+	media, ok := m.media[id]
+	streaming := ok && (media.reader != nil || media.streaming)
+	var data []byte
+	if ok {
+		data = media.data
+	}
+	m.mu.Unlock()
+	if !ok {
+		m.serveCached(w, r, id)
+		return
+	}
+	if media.path != "" {
+		http.ServeFile(w, r, media.path)
+		return
+	}
+	if streaming {
+		m.stream(w, r, id, media)
+		return
+	}
+	w.Header().Set("Content-Type", media.contentType)
+	if media.etag != "" {
+		// ServeContent answers If-None-Match with 304 Not Modified
+		w.Header().Set("ETag", media.etag)
+		// Not to be reused after it is evicted
+		maxAge := time.Until(media.expires)
+		if maxAge < 0 {
+			maxAge = 0
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(maxAge.Seconds())))
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// serveCached serves audio of key in Cache, such as to HTTPCache of another instance
+func (m *MediaServer) serveCached(w http.ResponseWriter, r *http.Request, key string) {
+	if m.Cache == nil || !isTTSCacheKey(key) {
+		http.NotFound(w, r)
+		return
+	}
+	audio, ok := m.Cache.Get(key)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "audio/mpeg")
+	w.Header().Set("ETag", `"`+key+`"`)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, immutable", int(mediaTTL.Seconds())))
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(audio))
+}
+
+// putCached stores audio of key sent by HTTPCache of another instance to Cache
+func (m *MediaServer) putCached(w http.ResponseWriter, r *http.Request, key string) {
+	if m.Cache == nil || !m.CacheWritable {
+		http.Error(w, "cache is not writable", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isTTSCacheKey(key) {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+	audio, err := io.ReadAll(io.LimitReader(r.Body, streamReplayMax+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(audio) > streamReplayMax {
+		http.Error(w, "audio too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	m.Cache.Put(key, audio)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stream copies reader of media to the response, flushing as data arrives,
+// and keeps what was read to serve it again
+func (m *MediaServer) stream(w http.ResponseWriter, r *http.Request, id string, media *servedMedia) {
+	w.Header().Set("Content-Type", media.contentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	m.mu.Lock()
+	reader := media.reader
+	media.reader = nil
+	media.streaming = true
+	m.mu.Unlock()
+	if reader == nil {
+		logf(m.Logger, "[ERROR] Stream requested again while streaming or too long to replay: id=%s", id)
+		http.Error(w, "stream already consumed", http.StatusGone)
+		return
+	}
+	defer closeReader(reader)
+
+	var replay bytes.Buffer
+	keep := true
+	defer func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if keep {
+			media.data, media.streaming = replay.Bytes(), false
+		} else {
+			delete(m.media, id)
+		}
+	}()
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if keep = keep && replay.Len()+n <= streamReplayMax; keep {
+				replay.Write(buf[:n])
+			} else {
+				replay = bytes.Buffer{}
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				logf(m.Logger, "[ERROR] Failed to stream media: %v", werr)
+				keep = false
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			logf(m.Logger, "[ERROR] Failed to read media stream: %v", err)
+			keep = false
+			return
+		}
+	}
+}
+
+// closeReader closes r if it is io.Closer, such as response body of streaming synthesis
+func closeReader(r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// outboundIP returns local address used to reach other hosts. No packet is sent.
+func outboundIP() (net.IP, error) {
+	conn, err := net.Dial("udp", "192.0.2.1:9")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,96 @@
+package homecast
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMediaServerReplaysStream(t *testing.T) {
+	m := &MediaServer{Host: "127.0.0.1"}
+	defer m.Close()
+	u, err := m.ServeReader(strings.NewReader("audio data"), "audio/mpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Later requests, such as retries, get what the first one streamed
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(u.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || string(body) != "audio data" {
+			t.Errorf("request %d: status=%d body=%q", i, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestMediaServerStreamTooLongToReplay(t *testing.T) {
+	m := &MediaServer{Host: "127.0.0.1"}
+	defer m.Close()
+	u, err := m.ServeReader(bytes.NewReader(make([]byte, streamReplayMax+1)), "audio/mpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if n != streamReplayMax+1 {
+		t.Errorf("streamed %d bytes, want %d", n, streamReplayMax+1)
+	}
+
+	resp, err = http.Get(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status of replay = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestMediaServerCacheOrigin(t *testing.T) {
+	cache := &MemoryCache{}
+	origin := &MediaServer{Host: "127.0.0.1", Cache: cache}
+	defer origin.Close()
+	u, err := origin.ServeBytes([]byte("started"), "audio/mpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &HTTPCache{Origin: "http://" + u.Host}
+	key := ttsCacheKey("hello", "en", Voice{})
+
+	// Read-only origin
+	c.Put(key, []byte("audio"))
+	if _, ok := cache.Get(key); ok {
+		t.Fatal("audio was put to cache which is not writable")
+	}
+
+	origin.CacheWritable = true
+	c.Put(key, []byte("audio"))
+	if audio, ok := cache.Get(key); !ok || string(audio) != "audio" {
+		t.Errorf("cache.Get() = %q, %v, want audio, true", audio, ok)
+	}
+	if audio, ok := c.Get(key); !ok || string(audio) != "audio" {
+		t.Errorf("Get() = %q, %v, want audio, true", audio, ok)
+	}
+	if _, ok := c.Get(ttsCacheKey("other", "en", Voice{})); ok {
+		t.Error("Get() hit unknown key")
+	}
+	// Keys not made by CachedTTS are never looked up, such as paths of DiskCache
+	if _, ok := c.Get("../secret"); ok {
+		t.Error("Get() hit invalid key")
+	}
+}
@@ -0,0 +1,36 @@
+package homecast
+
+import (
+	"sync"
+	"time"
+)
+
+// DiscoveryStats is a record of one discovery scan
+type DiscoveryStats struct {
+	Started  time.Time
+	Duration time.Duration
+	// Responses is the number of mDNS service entries received
+	Responses int
+	// Connected is the number of devices connected successfully
+	Connected int
+	// Failures is the number of devices failed to connect
+	Failures int
+}
+
+var (
+	discoveryStatsMu   sync.Mutex
+	lastDiscoveryStats DiscoveryStats
+)
+
+// LastDiscoveryStats returns stats of the most recent discovery scan
+func LastDiscoveryStats() DiscoveryStats {
+	discoveryStatsMu.Lock()
+	defer discoveryStatsMu.Unlock()
+	return lastDiscoveryStats
+}
+
+func recordDiscoveryStats(stats DiscoveryStats) {
+	discoveryStatsMu.Lock()
+	lastDiscoveryStats = stats
+	discoveryStatsMu.Unlock()
+}
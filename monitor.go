@@ -0,0 +1,150 @@
+package homecast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// monitorInterval is default interval of reachability checks
+	monitorInterval = 30 * time.Second
+	// pingTimeout bounds each reachability check
+	pingTimeout = 5 * time.Second
+)
+
+// Monitor periodically checks that devices are reachable, publishing DeviceOffline and DeviceOnline
+// when it changes
+type Monitor struct {
+	// Interval is how often devices are checked. Default is 30 seconds.
+	Interval time.Duration
+	// Webhook is url notified by POST with JSON {"device", "id", "online", "time"} on every change
+	Webhook string
+	// Clock is used to wait between checks. Default is the system clock.
+	Clock Clock
+	// Logger logs changes of reachability and webhook failures. Default logs nothing.
+	Logger Logger
+
+	devices []*CastDevice
+	mu      sync.RWMutex
+	offline map[*CastDevice]bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewMonitor creates a monitor of devices. Call Start to begin checks.
+func NewMonitor(devices ...*CastDevice) *Monitor {
+	return &Monitor{devices: devices, offline: map[*CastDevice]bool{}}
+}
+
+// Start begins checking devices every interval until Stop is called
+func (m *Monitor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	interval := m.Interval
+	if interval <= 0 {
+		interval = monitorInterval
+	}
+	clock := m.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for {
+			m.check(ctx)
+			select {
+			case <-clock.After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends checks and waits for the running one to return
+func (m *Monitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+}
+
+// Online reports whether device responded to the last check
+func (m *Monitor) Online(g *CastDevice) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return !m.offline[g]
+}
+
+// check pings every device and notifies changes of reachability
+func (m *Monitor) check(ctx context.Context) {
+	for _, g := range m.devices {
+		err := ping(ctx, g)
+		if ctx.Err() != nil {
+			return
+		}
+		m.mu.Lock()
+		wasOffline := m.offline[g]
+		m.offline[g] = err != nil
+		m.mu.Unlock()
+
+		switch {
+		case err != nil && !wasOffline:
+			logf(m.Logger, "[INFO] Device went offline: %s: %v", g.Name, err)
+			publish(DeviceOffline{Device: g, Err: err})
+			m.notify(ctx, g, false)
+		case err == nil && wasOffline:
+			logf(m.Logger, "[INFO] Device came back online: %s", g.Name)
+			publish(DeviceOnline{Device: g})
+			m.notify(ctx, g, true)
+		}
+	}
+}
+
+// notify posts change of reachability to the webhook if configured
+func (m *Monitor) notify(ctx context.Context, g *CastDevice, online bool) {
+	if m.Webhook == "" {
+		return
+	}
+	b, err := json.Marshal(map[string]interface{}{
+		"device": g.FriendlyName(),
+		"id":     g.ID(),
+		"online": online,
+		"time":   time.Now(),
+	})
+	if err != nil {
+		logf(m.Logger, "[ERROR] Failed to encode webhook: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, m.Webhook, bytes.NewReader(b))
+	if err != nil {
+		logf(m.Logger, "[ERROR] Failed to notify webhook: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		logf(m.Logger, "[ERROR] Failed to notify webhook: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logf(m.Logger, "[ERROR] Failed to notify webhook: %s", resp.Status)
+	}
+}
+
+// ping checks device responds to receiver status request
+func ping(ctx context.Context, g *CastDevice) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+	return g.withReconnect(ctx, func() error {
+		_, err := g.castClient().Receiver().GetStatus(ctx)
+		return err
+	})
+}
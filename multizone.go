@@ -0,0 +1,147 @@
+package homecast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/barnybug/go-cast"
+	castnet "github.com/barnybug/go-cast/net"
+)
+
+const multizoneNamespace = "urn:x-cast:com.google.cast.multizone"
+
+// Member is a device belonging to a stereo pair or a cast group
+type Member struct {
+	ID     string
+	Name   string
+	Volume float64
+	Muted  bool
+}
+
+type multizoneStatus struct {
+	Status struct {
+		Devices []struct {
+			DeviceID string `json:"deviceId"`
+			Name     string `json:"name"`
+			Volume   struct {
+				Level float64 `json:"level"`
+				Muted bool    `json:"muted"`
+			} `json:"volume"`
+		} `json:"devices"`
+		IsMultichannel bool `json:"isMultichannel"`
+	} `json:"status"`
+}
+
+// multizone queries members of the device over multizone namespace
+func (g *CastDevice) multizone(ctx context.Context) (*multizoneStatus, error) {
+	channel := g.castClient().NewChannel(cast.DefaultSender, cast.DefaultReceiver, multizoneNamespace)
+	msg, err := channel.Request(ctx, &castnet.PayloadHeaders{Type: "GET_STATUS"})
+	if err != nil {
+		return nil, err
+	}
+	status := &multizoneStatus{}
+	if err := json.Unmarshal([]byte(msg.GetPayloadUtf8()), status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (s *multizoneStatus) members() []Member {
+	members := make([]Member, len(s.Status.Devices))
+	for i, d := range s.Status.Devices {
+		members[i] = Member{ID: d.DeviceID, Name: d.Name, Volume: d.Volume.Level, Muted: d.Volume.Muted}
+	}
+	return members
+}
+
+// Members returns member devices of the cast group with their individual volumes
+func (g *CastDevice) Members(ctx context.Context) ([]Member, error) {
+	status, err := g.multizone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status.members(), nil
+}
+
+type setDeviceVolumeCommand struct {
+	castnet.PayloadHeaders
+	DeviceID string `json:"deviceId"`
+	Volume   struct {
+		Level float64 `json:"level"`
+	} `json:"volume"`
+}
+
+// SetMemberVolume changes volume level of a member device of the cast group. level is from 0 to 1.
+// The group's volume policy and ceiling apply.
+func (g *CastDevice) SetMemberVolume(ctx context.Context, memberID string, level float64) (err error) {
+	defer g.audit(ctx, "set_member_volume", fmt.Sprintf("%s %v", memberID, level), &err)
+	level, err = g.checkVolume(level, 0, 1)
+	if err != nil {
+		return err
+	}
+	if max := g.currentSettings().maxVolume; max > 0 && level > max {
+		level = max
+	}
+
+	cmd := &setDeviceVolumeCommand{
+		PayloadHeaders: castnet.PayloadHeaders{Type: "SET_DEVICE_VOLUME"},
+		DeviceID:       memberID,
+	}
+	cmd.Volume.Level = level
+	channel := g.castClient().NewChannel(cast.DefaultSender, cast.DefaultReceiver, multizoneNamespace)
+	_, err = channel.Request(ctx, cmd)
+	return err
+}
+
+// StereoPair returns the member speakers if the device is a stereo pair, or nil if it is not
+func (g *CastDevice) StereoPair(ctx context.Context) ([]Member, error) {
+	status, err := g.multizone(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !status.Status.IsMultichannel {
+		return nil, nil
+	}
+	return status.members(), nil
+}
+
+// DedupeTargets removes devices which are members of a stereo pair or a speaker group also in devices,
+// so that the pair or group is targeted instead of each of its speakers.
+func DedupeTargets(ctx context.Context, devices []*CastDevice) []*CastDevice {
+	paired := map[string]bool{}
+	for _, device := range devices {
+		var members []Member
+		var err error
+		if device.IsGroup() {
+			members, err = device.Members(ctx)
+		} else {
+			members, err = device.StereoPair(ctx)
+		}
+		if err != nil {
+			device.logf("[ERROR] Failed to get multizone status: %v", err)
+			continue
+		}
+		for _, m := range members {
+			if m.ID != "" {
+				paired[normalizeDeviceID(m.ID)] = true
+			}
+		}
+	}
+
+	targets := make([]*CastDevice, 0, len(devices))
+	for _, device := range devices {
+		if paired[normalizeDeviceID(device.ID())] {
+			device.logf("[INFO] Skip member of stereo pair or group: %s", device.Name)
+			continue
+		}
+		targets = append(targets, device)
+	}
+	return targets
+}
+
+// normalizeDeviceID converts device id of TXT record and multizone status into the same form
+func normalizeDeviceID(id string) string {
+	return strings.ToLower(strings.Replace(id, "-", "", -1))
+}
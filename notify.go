@@ -0,0 +1,151 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// notifyRestoreTimeout is deadline to restore playback after Notify, independent of its ctx
+const notifyRestoreTimeout = 10 * time.Second
+
+// playbackSnapshot is state of cast device to restore after a notification
+type playbackSnapshot struct {
+	volume float64
+	// media is nil when nothing was playing on the default media receiver
+	media    *mediaData
+	position float64
+	playing  bool
+	// queue is items of the queue media belongs to, including media at index current.
+	// It is empty if the queue could not be read, then only media is restored.
+	queue   []queueItem
+	current int
+	repeat  RepeatMode
+}
+
+// Notify speaks text as an interruption: it remembers volume and media playing on cast device,
+// speaks text, then restores the volume and resumes the media from where it was,
+// along with the rest of its queue, metadata and repeat mode.
+// Media of other apps than the default media receiver can't be resumed.
+func (g *CastDevice) Notify(ctx context.Context, text, lang string, opts ...PlayOption) error {
+	snap, err := g.snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	err = g.SpeakAndWait(ctx, text, lang, opts...)
+
+	// ctx may be already cancelled here
+	restoreCtx, cancel := context.WithTimeout(context.Background(), notifyRestoreTimeout)
+	defer cancel()
+	rerr := g.restore(restoreCtx, snap)
+	g.audit(ctx, "restore", "", &rerr)
+	if rerr != nil {
+		if err != nil {
+			g.logf("[ERROR] Failed to restore playback: %v", rerr)
+			return err
+		}
+		return rerr
+	}
+	return err
+}
+
+// snapshot records current volume and media of cast device
+func (g *CastDevice) snapshot(ctx context.Context) (*playbackSnapshot, error) {
+	volume, err := g.volume(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snap := &playbackSnapshot{volume: volume}
+
+	s, err := g.attachMedia(ctx)
+	if errors.Is(err, ErrNoMediaSession) {
+		return snap, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	status, err := s.status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil || status.Media == nil || status.PlayerState == "IDLE" {
+		return snap, nil
+	}
+	snap.media = &mediaData{
+		ContentId:   status.Media.ContentId,
+		ContentType: status.Media.ContentType,
+		StreamType:  status.Media.StreamType,
+	}
+	snap.position = status.CurrentTime
+	snap.playing = status.PlayerState != "PAUSED"
+	if err := s.snapshotQueue(ctx, snap); err != nil {
+		// Media alone is still resumed
+		g.logf("[ERROR] Failed to snapshot queue: %v", err)
+		snap.queue = nil
+	}
+	g.logf("[INFO] Snapshot playback: content_id=%s position=%.1f", snap.media.ContentId, snap.position)
+	return snap, nil
+}
+
+// snapshotQueue records items of the queue playing media of snap
+func (s *mediaSession) snapshotQueue(ctx context.Context, snap *playbackSnapshot) error {
+	status, err := s.queueStatus(ctx)
+	if err != nil {
+		return err
+	}
+	ids, err := s.itemIDs(ctx)
+	if err != nil {
+		return err
+	}
+	media, err := s.itemMedia(ctx, ids)
+	if err != nil {
+		return err
+	}
+	current, ok := media[status.CurrentItemID]
+	if !ok || current.ContentId != snap.media.ContentId {
+		return fmt.Errorf("homecast: current item %d is not in the queue", status.CurrentItemID)
+	}
+	// Metadata is not given by media status
+	snap.media.Metadata = current.Metadata
+	snap.repeat = status.RepeatMode
+	for _, id := range ids {
+		m, ok := media[id]
+		if !ok {
+			continue
+		}
+		if id == status.CurrentItemID {
+			snap.current = len(snap.queue)
+		}
+		snap.queue = append(snap.queue, queueItem{Media: m, Autoplay: true})
+	}
+	return nil
+}
+
+// restore sets volume back and reloads media recorded by snapshot
+func (g *CastDevice) restore(ctx context.Context, snap *playbackSnapshot) error {
+	if err := g.setVolume(ctx, snap.volume); err != nil {
+		return err
+	}
+	if snap.media == nil {
+		return nil
+	}
+	s, err := g.launchMedia(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	position := snap.position
+	if snap.media.StreamType == "LIVE" {
+		// Live streams such as radio resume at live edge
+		position = 0
+	}
+	g.logf("[INFO] Resume playback: content_id=%s position=%.1f queue=%d", snap.media.ContentId, position, len(snap.queue))
+	if len(snap.queue) > 1 {
+		items := append([]queueItem(nil), snap.queue...)
+		items[snap.current].Autoplay = snap.playing
+		return s.queueLoadAt(ctx, items, snap.current, position, snap.repeat)
+	}
+	return s.loadAt(ctx, *snap.media, position, snap.playing)
+}
@@ -0,0 +1,94 @@
+package homecast
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/barnybug/go-cast"
+	"github.com/micro/mdns"
+)
+
+// defaultDialTimeout is how long NewCastDevice waits for connecting by default
+const defaultDialTimeout = 10 * time.Second
+
+// Logger is destination of log messages. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Option configures CastDevice
+type Option func(*CastDevice)
+
+// WithDialTimeout sets how long NewCastDevice waits for connecting. Default is 10 seconds.
+func WithDialTimeout(d time.Duration) Option {
+	return func(g *CastDevice) {
+		g.dialTimeout = d
+	}
+}
+
+// WithLogger sets logger of cast device. Default logs nothing.
+func WithLogger(l Logger) Option {
+	return func(g *CastDevice) {
+		g.logger = l
+	}
+}
+
+// WithAutoReconnect makes cast device re-dial dropped connections transparently, with exponential backoff
+func WithAutoReconnect() Option {
+	return func(g *CastDevice) {
+		g.settings.autoReconnect = true
+	}
+}
+
+// NewCastDevice connects to cast device at addr and port without mDNS discovery,
+// for devices whose address is already known such as from static config
+func NewCastDevice(addr net.IP, port int, opts ...Option) (*CastDevice, error) {
+	g := newCastDevice(addrEntry(net.JoinHostPort(addr.String(), strconv.Itoa(port)), addr, port), opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.dialTimeout)
+	defer cancel()
+	if err := g.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// newCastDevice returns cast device of entry, not connected yet
+func newCastDevice(entry *mdns.ServiceEntry, opts []Option) *CastDevice {
+	g := &CastDevice{
+		ServiceEntry: entry,
+		client:       cast.NewClient(entryAddr(entry), entry.Port),
+		txt:          ParseCastTXT(entry.InfoFields),
+		dialTimeout:  defaultDialTimeout,
+	}
+	g.ctx, g.cancel = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// addrEntry returns service entry of cast device at ip and port
+func addrEntry(name string, ip net.IP, port int) *mdns.ServiceEntry {
+	entry := &mdns.ServiceEntry{Name: name, Port: port}
+	if ip.To4() != nil {
+		entry.AddrV4 = ip
+	} else {
+		entry.AddrV6 = ip
+	}
+	return entry
+}
+
+// logf logs by logger of cast device. Nothing is logged if not set.
+func (g *CastDevice) logf(format string, v ...interface{}) {
+	logf(g.logger, format, v...)
+}
+
+// logf logs by l. Nothing is logged if l is nil.
+func logf(l Logger, format string, v ...interface{}) {
+	if l != nil {
+		l.Printf(format, v...)
+	}
+}
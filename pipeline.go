@@ -0,0 +1,100 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrUnknownPipeline is returned when a pipeline or its stage is not registered
+var ErrUnknownPipeline = errors.New("homecast: unknown pipeline")
+
+// Processor pre-processes media before it is loaded on cast device, such as normalizing loudness,
+// transcoding or caching. It returns url of the processed media.
+type Processor interface {
+	Process(ctx context.Context, u *url.URL) (*url.URL, error)
+}
+
+// ProcessorFunc adapts a function to Processor
+type ProcessorFunc func(ctx context.Context, u *url.URL) (*url.URL, error)
+
+// Process calls f
+func (f ProcessorFunc) Process(ctx context.Context, u *url.URL) (*url.URL, error) {
+	return f(ctx, u)
+}
+
+// Pipeline is a Processor running processors in order, each given the output of the previous one
+type Pipeline []Processor
+
+// Process runs each processor in order
+func (p Pipeline) Process(ctx context.Context, u *url.URL) (*url.URL, error) {
+	for _, processor := range p {
+		var err error
+		if u, err = processor.Process(ctx, u); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+var (
+	processorRegistry = map[string]Processor{}
+	pipelineRegistry  = map[string]Pipeline{}
+)
+
+// RegisterProcessor makes processor available by name as a pipeline stage
+func RegisterProcessor(name string, p Processor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	processorRegistry[name] = p
+}
+
+// RegisterPipeline declares named pipeline of processors registered by stage names,
+// such as "normalize", "transcode" and "cache", so that it can be built from config
+func RegisterPipeline(name string, stages ...string) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	pipeline := make(Pipeline, 0, len(stages))
+	for _, stage := range stages {
+		p, ok := processorRegistry[stage]
+		if !ok {
+			return fmt.Errorf("%w: stage %q of %q", ErrUnknownPipeline, stage, name)
+		}
+		pipeline = append(pipeline, p)
+	}
+	pipelineRegistry[name] = pipeline
+	return nil
+}
+
+// LookupPipeline returns pipeline registered by name
+func LookupPipeline(name string) (Pipeline, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := pipelineRegistry[name]
+	return p, ok
+}
+
+// WithPipeline makes Play and Speak pre-process media with the pipeline registered by name
+func WithPipeline(name string) PlayOption {
+	return func(o *playOptions) {
+		o.pipeline = name
+	}
+}
+
+// process runs pipeline selected by options on u
+func (o *playOptions) process(ctx context.Context, g *CastDevice, u *url.URL) (*url.URL, error) {
+	if o.pipeline == "" {
+		return u, nil
+	}
+	p, ok := LookupPipeline(o.pipeline)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPipeline, o.pipeline)
+	}
+	processed, err := p.Process(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	g.logf("[INFO] Processed media: pipeline=%s %s -> %s", o.pipeline, u, processed)
+	return processed, nil
+}
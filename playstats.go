@@ -0,0 +1,79 @@
+package homecast
+
+import (
+	"context"
+	"time"
+)
+
+// completedMargin is how close to the end a track must be played to count as completed,
+// in addition to the interval progress is sampled at
+const completedMargin = 2 * time.Second
+
+// TrackStats is playback statistics of one track, such as for scrobbling or diagnostics
+type TrackStats struct {
+	Device    *CastDevice
+	ContentID string
+	Duration  time.Duration
+	// Listened is time the track was actually playing, excluding pauses and buffering
+	Listened time.Duration
+	// Completed is true when the track played to its end, otherwise it was skipped or stopped
+	Completed bool
+	// Underruns counts how many times playback stalled for buffering
+	Underruns int
+	// BufferingTime is total time spent buffering
+	BufferingTime time.Duration
+}
+
+// RecordPlayback collects playback statistics from media status of cast device and emits them
+// when each track ends, either played through or skipped. They are also published as TrackFinished.
+// The channel is closed when ctx is done or the media session has ended.
+func (g *CastDevice) RecordPlayback(ctx context.Context, interval time.Duration) (<-chan TrackStats, error) {
+	progress, err := g.TrackProgress(ctx, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan TrackStats)
+	go func() {
+		defer close(ch)
+
+		var cur *TrackStats
+		var last Progress
+		var lastAt time.Time
+		emit := func() bool {
+			if cur == nil {
+				return true
+			}
+			cur.Underruns = last.Underruns - cur.Underruns
+			cur.BufferingTime = last.BufferingTime - cur.BufferingTime
+			cur.Duration = last.Duration
+			cur.Completed = last.Duration > 0 && last.Position >= last.Duration-completedMargin-interval
+			stats := *cur
+			cur = nil
+			publish(TrackFinished{stats})
+			select {
+			case ch <- stats:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for p := range progress {
+			now := g.clk().Now()
+			if cur != nil && p.ContentID != cur.ContentID {
+				if !emit() {
+					return
+				}
+			}
+			if cur == nil {
+				// Underruns and BufferingTime hold values at start of the track until it is emitted
+				cur = &TrackStats{Device: g, ContentID: p.ContentID, Underruns: p.Underruns, BufferingTime: p.BufferingTime}
+			} else if last.PlayerState == "PLAYING" {
+				cur.Listened += now.Sub(lastAt)
+			}
+			last, lastAt = p, now
+		}
+		emit()
+	}()
+	return ch, nil
+}
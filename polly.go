@@ -0,0 +1,161 @@
+package homecast
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// pollyVoices are default voices for languages
+var pollyVoices = map[string]string{
+	"en": "Joanna",
+	"ja": "Mizuki",
+	"de": "Marlene",
+	"fr": "Celine",
+	"es": "Conchita",
+	"it": "Carla",
+}
+
+// Polly is TTSProvider using Amazon Polly.
+// It returns presigned url of SynthesizeSpeech, so cast devices fetch audio from Polly directly.
+type Polly struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is required for temporary credentials
+	SessionToken string
+	Region       string
+	// VoiceID such as "Joanna". Default voice of the language is used when empty.
+	VoiceID string
+	// Engine is "standard" or "neural". Default is standard.
+	Engine string
+	// Expires is validity of presigned url. Default is 15 minutes.
+	Expires time.Duration
+}
+
+// MaxTextLength returns billed character limit of SynthesizeSpeech
+func (p *Polly) MaxTextLength() int {
+	return 3000
+}
+
+// Synthesize returns presigned url which synthesizes text
+func (p *Polly) Synthesize(ctx context.Context, text, lang string) (*url.URL, error) {
+	return p.SynthesizeRate(ctx, text, lang, 1)
+}
+
+// SynthesizeRate returns presigned url of speech of text spoken at rate times the normal speed
+func (p *Polly) SynthesizeRate(ctx context.Context, text, lang string, rate float64) (*url.URL, error) {
+	if rate == 1 {
+		return p.synthesize(text, "text", lang, "")
+	}
+	return p.SynthesizeSSML(ctx, "<speak>"+escapeSSML(text)+"</speak>", lang, Voice{Rate: rate})
+}
+
+// SynthesizeSSML returns presigned url of speech of SSML document with voice.
+// Name of voice replaces VoiceID of p, and its rate and pitch are applied by prosody.
+func (p *Polly) SynthesizeSSML(ctx context.Context, ssml, lang string, voice Voice) (*url.URL, error) {
+	return p.synthesize(withProsody(ssml, voice), "ssml", lang, voice.Name)
+}
+
+func (p *Polly) synthesize(text, textType, lang, voice string) (*url.URL, error) {
+	if voice == "" {
+		voice = p.VoiceID
+	}
+	if voice == "" {
+		voice = pollyVoices[strings.SplitN(lang, "-", 2)[0]]
+	}
+	if voice == "" {
+		return nil, fmt.Errorf("homecast: no polly voice for language %s", lang)
+	}
+
+	params := url.Values{}
+	params.Set("OutputFormat", "mp3")
+	params.Set("Text", text)
+	params.Set("TextType", textType)
+	params.Set("VoiceId", voice)
+	if p.Engine != "" {
+		params.Set("Engine", p.Engine)
+	}
+	return p.presign("/v1/speech", params, time.Now().UTC()), nil
+}
+
+// presign signs GET request of path with AWS Signature Version 4 in query string
+func (p *Polly) presign(path string, params url.Values, now time.Time) *url.URL {
+	expires := p.Expires
+	if expires == 0 {
+		expires = 15 * time.Minute
+	}
+	emptyHash := sha256.Sum256(nil)
+	creds := awsCredentials{p.AccessKeyID, p.SecretAccessKey, p.SessionToken, p.Region}
+	u := &url.URL{Scheme: "https", Host: fmt.Sprintf("polly.%s.amazonaws.com", p.Region), Path: path}
+	u.RawQuery = creds.presign("GET", u.Host, path, "polly", hex.EncodeToString(emptyHash[:]), params, expires, now)
+	return u
+}
+
+// awsCredentials sign requests to AWS compatible services in region
+type awsCredentials struct {
+	accessKeyID, secretAccessKey, sessionToken, region string
+}
+
+// presign returns query string of request of method to escaped path on host of service,
+// signed with AWS Signature Version 4 and valid for expires since now
+func (c awsCredentials) presign(method, host, path, service, payloadHash string, params url.Values, expires time.Duration, now time.Time) string {
+	date := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z")
+	scope := date + "/" + c.region + "/" + service + "/aws4_request"
+
+	params.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	params.Set("X-Amz-Credential", c.accessKeyID+"/"+scope)
+	params.Set("X-Amz-Date", amzDate)
+	params.Set("X-Amz-Expires", fmt.Sprint(int(expires.Seconds())))
+	params.Set("X-Amz-SignedHeaders", "host")
+	if c.sessionToken != "" {
+		params.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	query := canonicalQuery(params)
+	canonicalRequest := strings.Join([]string{
+		method, path, query, "host:" + host + "\n", "host", payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretAccessKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	return query + "&X-Amz-Signature=" + hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// canonicalQuery encodes params sorted by key with RFC 3986 escaping
+func canonicalQuery(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range params[k] {
+			pairs = append(pairs, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,28 @@
+package homecast
+
+// Pool bounds the number of goroutines used by fan-out operations such as discovery,
+// and by runs of Scheduler jobs. Loops waiting for schedules don't hold a slot.
+type Pool struct {
+	sem chan struct{}
+}
+
+// DefaultPool is shared by fan-out operations of this package.
+// Replace it to change concurrency limit.
+var DefaultPool = NewPool(16)
+
+// NewPool creates a pool which runs at most size functions at once
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	return &Pool{sem: make(chan struct{}, size)}
+}
+
+// Go runs f on a new goroutine, waiting for a free slot if the pool is full
+func (p *Pool) Go(f func()) {
+	p.sem <- struct{}{}
+	go func() {
+		defer func() { <-p.sem }()
+		f()
+	}()
+}
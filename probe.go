@@ -0,0 +1,91 @@
+package homecast
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// mediaInfo is what probeURL learned about media url
+type mediaInfo struct {
+	ContentType string
+	// Size is content length in bytes, or -1 when unknown
+	Size int64
+}
+
+// probeURL checks media url is reachable with HEAD, falling back to a ranged GET
+// for servers which don't support HEAD.
+func probeURL(ctx context.Context, u *url.URL) (*mediaInfo, error) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("homecast: unsupported url scheme: %s", u)
+	}
+
+	resp, err := probe(ctx, http.MethodHead, u)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = probe(ctx, http.MethodGet, u)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("homecast: media url returned %s: %s", resp.Status, u)
+	}
+	return &mediaInfo{ContentType: resp.Header.Get("Content-Type"), Size: resp.ContentLength}, nil
+}
+
+// sniffContentType guesses content type of media from the url extension, then from the response
+func sniffContentType(ctx context.Context, u *url.URL) string {
+	if ct := mime.TypeByExtension(path.Ext(u.Path)); isMediaType(ct) {
+		return ct
+	}
+	if info, err := probeURL(ctx, u); err == nil && isMediaType(info.ContentType) {
+		if ct, _, err := mime.ParseMediaType(info.ContentType); err == nil {
+			return ct
+		}
+	}
+	return defaultContentType
+}
+
+func isMediaType(ct string) bool {
+	return strings.HasPrefix(ct, "audio/") || strings.HasPrefix(ct, "video/") ||
+		strings.HasPrefix(ct, "application/x-mpegurl") || strings.HasPrefix(ct, "application/vnd.apple.mpegurl") ||
+		strings.HasPrefix(ct, "application/dash+xml")
+}
+
+// preflight checks media url is playable before loading it on cast device
+func preflight(ctx context.Context, u *url.URL) error {
+	info, err := probeURL(ctx, u)
+	if err != nil {
+		return err
+	}
+	if info.Size == 0 {
+		return fmt.Errorf("homecast: media url is empty: %s", u)
+	}
+	ct := info.ContentType
+	if ct != "" && !strings.HasPrefix(ct, "audio/") && !strings.HasPrefix(ct, "video/") &&
+		!strings.HasPrefix(ct, "application/") {
+		return fmt.Errorf("homecast: media url is not audio: content_type=%s url=%s", ct, u)
+	}
+	return nil
+}
+
+func probe(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequest(method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
@@ -0,0 +1,125 @@
+package homecast
+
+import (
+	"context"
+	"time"
+
+	"github.com/barnybug/go-cast/controllers"
+)
+
+// progressPollInterval is how often TrackProgress asks the receiver for media status
+const progressPollInterval = 5 * time.Second
+
+// Progress is playback progress of media on cast device
+type Progress struct {
+	ContentID   string
+	PlayerState string
+	Position    time.Duration
+	Duration    time.Duration
+	// Buffering is true while the receiver is waiting for data
+	Buffering bool
+	// Underruns counts how many times playback stalled for buffering after it had started
+	Underruns int
+	// BufferingTime is total time spent buffering since tracking started
+	BufferingTime time.Duration
+}
+
+// TrackProgress emits playback progress of current media every interval.
+// Position is interpolated between status updates from the receiver.
+// The channel is closed when ctx is done or the media session has ended.
+func (g *CastDevice) TrackProgress(ctx context.Context, interval time.Duration) (<-chan Progress, error) {
+	s, err := g.attachMedia(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status, err := s.status(ctx)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	ch := make(chan Progress)
+	go func() {
+		defer close(ch)
+		defer s.Close()
+
+		var underruns int
+		var buffering time.Duration
+		played := status != nil && status.PlayerState == "PLAYING"
+		updated, last := s.clock.Now(), s.clock.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-s.clock.After(interval):
+				if status != nil && status.PlayerState == "BUFFERING" {
+					buffering += now.Sub(last)
+				}
+				last = now
+				if now.Sub(updated) >= progressPollInterval {
+					st, err := s.status(ctx)
+					if err != nil {
+						g.logf("[ERROR] Failed to get media status: %v", err)
+						return
+					}
+					if st != nil && st.PlayerState == "BUFFERING" && played && status.PlayerState != "BUFFERING" {
+						underruns++
+						g.logf("[INFO] Buffering underrun: %s", g.Name)
+					}
+					if st != nil && st.PlayerState == "PLAYING" {
+						played = true
+					}
+					status, updated = st, now
+				}
+				if status == nil || status.PlayerState == "IDLE" {
+					return
+				}
+				p := newProgress(status, now.Sub(updated))
+				p.Underruns = underruns
+				p.BufferingTime = buffering
+				select {
+				case ch <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// status returns current media status, or nil when nothing is loaded
+func (s *mediaSession) status(ctx context.Context) (*controllers.MediaStatus, error) {
+	resp, err := s.media.GetStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Status) == 0 {
+		return nil, nil
+	}
+	return resp.Status[0], nil
+}
+
+// newProgress interpolates position of status after elapsed
+func newProgress(status *controllers.MediaStatus, elapsed time.Duration) Progress {
+	p := Progress{
+		PlayerState: status.PlayerState,
+		Position:    seconds(status.CurrentTime),
+		Buffering:   status.PlayerState == "BUFFERING",
+	}
+	if status.PlayerState == "PLAYING" {
+		p.Position += time.Duration(float64(elapsed) * status.PlaybackRate)
+	}
+	if status.Media != nil {
+		p.ContentID = status.Media.ContentId
+		p.Duration = seconds(status.Media.Duration)
+		if p.Duration > 0 && p.Position > p.Duration {
+			p.Position = p.Duration
+		}
+	}
+	return p
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,438 @@
+package homecast
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	castnet "github.com/barnybug/go-cast/net"
+)
+
+// QueuePosition tells where QueueInsert inserts items
+type QueuePosition struct {
+	next   bool
+	before int
+}
+
+var (
+	// InsertAtEnd inserts items at the end of the queue
+	InsertAtEnd = QueuePosition{}
+	// InsertNext inserts items right after the item currently playing
+	InsertNext = QueuePosition{next: true}
+)
+
+// InsertBefore inserts items before the queue item of itemID
+func InsertBefore(itemID int) QueuePosition {
+	return QueuePosition{before: itemID}
+}
+
+type queueInsertCommand struct {
+	castnet.PayloadHeaders
+	MediaSessionID int         `json:"mediaSessionId"`
+	Items          []queueItem `json:"items"`
+	InsertBefore   int         `json:"insertBefore,omitempty"`
+}
+
+type queueGetItemIDsCommand struct {
+	castnet.PayloadHeaders
+	MediaSessionID int `json:"mediaSessionId"`
+}
+
+type queueItemIDsResponse struct {
+	castnet.PayloadHeaders
+	ItemIDs []int `json:"itemIds"`
+}
+
+type queueStatusResponse struct {
+	castnet.PayloadHeaders
+	Status []queueMediaStatus `json:"status"`
+}
+
+type queueMediaStatus struct {
+	MediaSessionID int        `json:"mediaSessionId"`
+	CurrentItemID  int        `json:"currentItemId"`
+	RepeatMode     RepeatMode `json:"repeatMode"`
+}
+
+// QueueResult is the queue items added by QueueLoad or QueueInsert
+type QueueResult struct {
+	// ItemIDs are ids the receiver assigned to the given urls in order,
+	// to be referenced by QueueJump, QueueRemove and QueueReorder
+	ItemIDs []int
+	// MediaSessionID is id of the media session playing the queue
+	MediaSessionID int
+	// SessionID is id of the media receiver app session
+	SessionID string
+}
+
+// QueueInsert inserts media urls into the queue loaded on cast device at pos.
+// Inserts to the same device are sequenced, so that items of rapid successive calls keep the call order.
+func (g *CastDevice) QueueInsert(ctx context.Context, pos QueuePosition, urls ...*url.URL) (result *QueueResult, err error) {
+	defer g.audit(ctx, "queue_insert", fmt.Sprint(urls), &err)
+	items := make([]queueItem, len(urls))
+	for i, u := range urls {
+		items[i] = newQueueItem(u, playOptions{})
+		items[i].PreloadTime = queuePreloadTime
+	}
+	err = g.withQueueSession(ctx, func(s *mediaSession) error {
+		before, err := s.insertBefore(ctx, pos)
+		if err != nil {
+			return err
+		}
+		existing, err := s.itemIDs(ctx)
+		if err != nil {
+			return err
+		}
+		err = s.request(ctx, &queueInsertCommand{
+			PayloadHeaders: castnet.PayloadHeaders{Type: "QUEUE_INSERT"},
+			MediaSessionID: s.media.MediaSessionID,
+			Items:          items,
+			InsertBefore:   before,
+		})
+		if err != nil {
+			return err
+		}
+		ids, err := s.itemIDs(ctx)
+		if err != nil {
+			return err
+		}
+		result = s.queueResult(newItemIDs(existing, ids))
+		return nil
+	})
+	return result, err
+}
+
+// newItemIDs returns ids not in existing, in the order of ids
+func newItemIDs(existing, ids []int) []int {
+	seen := make(map[int]bool, len(existing))
+	for _, id := range existing {
+		seen[id] = true
+	}
+	var added []int
+	for _, id := range ids {
+		if !seen[id] {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// queueResult returns QueueResult of itemIDs added to the queue of media session
+func (s *mediaSession) queueResult(itemIDs []int) *QueueResult {
+	return &QueueResult{ItemIDs: itemIDs, MediaSessionID: s.media.MediaSessionID, SessionID: s.sessionID}
+}
+
+// insertBefore returns item id pos refers to, or 0 for the end of the queue
+func (s *mediaSession) insertBefore(ctx context.Context, pos QueuePosition) (int, error) {
+	if pos.next {
+		return s.nextItemID(ctx)
+	}
+	return pos.before, nil
+}
+
+// nextItemID returns id of the queue item after the current one, or 0 if the current one is the last
+func (s *mediaSession) nextItemID(ctx context.Context) (int, error) {
+	status, err := s.queueStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := s.itemIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		if id == status.CurrentItemID {
+			if i+1 < len(ids) {
+				return ids[i+1], nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, fmt.Errorf("homecast: current item %d is not in the queue", status.CurrentItemID)
+}
+
+// queueStatus returns the current item and repeat mode of the queue
+func (s *mediaSession) queueStatus(ctx context.Context) (*queueMediaStatus, error) {
+	msg, err := s.channel.Request(ctx, &castnet.PayloadHeaders{Type: "GET_STATUS"})
+	if err != nil {
+		return nil, err
+	}
+	var status queueStatusResponse
+	if err := json.Unmarshal([]byte(msg.GetPayloadUtf8()), &status); err != nil {
+		return nil, err
+	}
+	if len(status.Status) == 0 {
+		return nil, ErrNoMediaSession
+	}
+	return &status.Status[0], nil
+}
+
+// itemIDs returns ids of queue items in order
+func (s *mediaSession) itemIDs(ctx context.Context) ([]int, error) {
+	msg, err := s.channel.Request(ctx, &queueGetItemIDsCommand{
+		PayloadHeaders: castnet.PayloadHeaders{Type: "QUEUE_GET_ITEM_IDS"},
+		MediaSessionID: s.media.MediaSessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ids queueItemIDsResponse
+	if err := json.Unmarshal([]byte(msg.GetPayloadUtf8()), &ids); err != nil {
+		return nil, err
+	}
+	return ids.ItemIDs, nil
+}
+
+type queueRemoveCommand struct {
+	castnet.PayloadHeaders
+	MediaSessionID int   `json:"mediaSessionId"`
+	ItemIDs        []int `json:"itemIds"`
+}
+
+type queueReorderCommand struct {
+	castnet.PayloadHeaders
+	MediaSessionID int   `json:"mediaSessionId"`
+	ItemIDs        []int `json:"itemIds"`
+	InsertBefore   int   `json:"insertBefore,omitempty"`
+}
+
+// QueueLoad loads media urls as a queue on cast device and starts playing from the first one
+func (g *CastDevice) QueueLoad(ctx context.Context, mode RepeatMode, urls ...*url.URL) (result *QueueResult, err error) {
+	defer g.audit(ctx, "queue_load", fmt.Sprint(mode, urls), &err)
+	items := make([]queueItem, len(urls))
+	for i, u := range urls {
+		items[i] = newQueueItem(u, playOptions{})
+		items[i].PreloadTime = queuePreloadTime
+	}
+	s, err := g.launchMedia(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	if err := s.queueLoad(ctx, items, mode); err != nil {
+		return nil, err
+	}
+	status, err := s.queueStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.media.MediaSessionID = status.MediaSessionID
+	ids, err := s.itemIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.queueResult(ids), nil
+}
+
+// QueueJump skips to the queue item of itemID
+func (g *CastDevice) QueueJump(ctx context.Context, itemID int) (err error) {
+	defer g.audit(ctx, "queue_jump", fmt.Sprint(itemID), &err)
+	return g.queueUpdate(ctx, queueUpdateCommand{CurrentItemID: itemID})
+}
+
+// QueueNext skips to the next item of the queue
+func (g *CastDevice) QueueNext(ctx context.Context) (err error) {
+	defer g.audit(ctx, "queue_next", "", &err)
+	return g.queueUpdate(ctx, queueUpdateCommand{Jump: 1})
+}
+
+// QueuePrev goes back to the previous item of the queue
+func (g *CastDevice) QueuePrev(ctx context.Context) (err error) {
+	defer g.audit(ctx, "queue_prev", "", &err)
+	return g.queueUpdate(ctx, queueUpdateCommand{Jump: -1})
+}
+
+// QueueShuffle shuffles the queue, or restores its order when shuffle is false
+func (g *CastDevice) QueueShuffle(ctx context.Context, shuffle bool) (err error) {
+	defer g.audit(ctx, "queue_shuffle", fmt.Sprint(shuffle), &err)
+	return g.queueUpdate(ctx, queueUpdateCommand{Shuffle: &shuffle})
+}
+
+// QueueSetRepeatMode changes repeat mode of the queue
+func (g *CastDevice) QueueSetRepeatMode(ctx context.Context, mode RepeatMode) (err error) {
+	defer g.audit(ctx, "queue_set_repeat_mode", string(mode), &err)
+	return g.queueUpdate(ctx, queueUpdateCommand{RepeatMode: mode})
+}
+
+// QueueRemove removes queue items of itemIDs
+func (g *CastDevice) QueueRemove(ctx context.Context, itemIDs ...int) (err error) {
+	defer g.audit(ctx, "queue_remove", fmt.Sprint(itemIDs), &err)
+	return g.withQueue(ctx, func(s *mediaSession) (castnet.Payload, error) {
+		return &queueRemoveCommand{
+			PayloadHeaders: castnet.PayloadHeaders{Type: "QUEUE_REMOVE"},
+			MediaSessionID: s.media.MediaSessionID,
+			ItemIDs:        itemIDs,
+		}, nil
+	})
+}
+
+// QueueReorder moves queue items of itemIDs, in the given order, to pos
+func (g *CastDevice) QueueReorder(ctx context.Context, pos QueuePosition, itemIDs ...int) (err error) {
+	defer g.audit(ctx, "queue_reorder", fmt.Sprint(itemIDs), &err)
+	return g.withQueue(ctx, func(s *mediaSession) (castnet.Payload, error) {
+		before, err := s.insertBefore(ctx, pos)
+		if err != nil {
+			return nil, err
+		}
+		return &queueReorderCommand{
+			PayloadHeaders: castnet.PayloadHeaders{Type: "QUEUE_REORDER"},
+			MediaSessionID: s.media.MediaSessionID,
+			ItemIDs:        itemIDs,
+			InsertBefore:   before,
+		}, nil
+	})
+}
+
+// queueUpdate sends QUEUE_UPDATE with fields of cmd
+func (g *CastDevice) queueUpdate(ctx context.Context, cmd queueUpdateCommand) error {
+	return g.withQueue(ctx, func(s *mediaSession) (castnet.Payload, error) {
+		cmd.PayloadHeaders = castnet.PayloadHeaders{Type: "QUEUE_UPDATE"}
+		cmd.MediaSessionID = s.media.MediaSessionID
+		return &cmd, nil
+	})
+}
+
+// withQueue sends queue command built by f to the current media session
+func (g *CastDevice) withQueue(ctx context.Context, f func(s *mediaSession) (castnet.Payload, error)) error {
+	return g.withQueueSession(ctx, func(s *mediaSession) error {
+		cmd, err := f(s)
+		if err != nil {
+			return err
+		}
+		return s.request(ctx, cmd)
+	})
+}
+
+// withQueueSession runs f with the current media session.
+// Queue commands to the same device are sequenced, so that they apply in the call order.
+func (g *CastDevice) withQueueSession(ctx context.Context, f func(s *mediaSession) error) error {
+	if err := g.require(ctx, "queue"); err != nil {
+		return err
+	}
+	g.queueMu.Lock()
+	defer g.queueMu.Unlock()
+
+	s, err := g.attachMedia(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return f(s)
+}
+
+// request sends cmd to media session and checks its response
+func (s *mediaSession) request(ctx context.Context, cmd castnet.Payload) error {
+	msg, err := s.channel.Request(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	return checkResponse(msg)
+}
+
+// QueueItem is an item of the queue loaded on cast device
+type QueueItem struct {
+	ItemID    int
+	ContentID string
+	Title     string
+	Artist    string
+	AlbumName string
+	// Active is true for the item currently playing
+	Active bool
+}
+
+// QueueStatus is the queue loaded on cast device
+type QueueStatus struct {
+	// Items are the queue items in play order
+	Items         []QueueItem
+	CurrentItemID int
+	RepeatMode    RepeatMode
+}
+
+type queueGetItemsCommand struct {
+	castnet.PayloadHeaders
+	MediaSessionID int   `json:"mediaSessionId"`
+	ItemIDs        []int `json:"itemIds"`
+}
+
+type queueItemsResponse struct {
+	castnet.PayloadHeaders
+	Items []struct {
+		ItemID int       `json:"itemId"`
+		Media  mediaData `json:"media"`
+	} `json:"items"`
+}
+
+// QueueStatus returns items of the queue loaded on cast device and which one is playing
+func (g *CastDevice) QueueStatus(ctx context.Context) (*QueueStatus, error) {
+	if err := g.require(ctx, "queue"); err != nil {
+		return nil, err
+	}
+	s, err := g.attachMedia(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	status, err := s.queueStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := s.itemIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.items(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		items[i].Active = items[i].ItemID == status.CurrentItemID
+	}
+	return &QueueStatus{Items: items, CurrentItemID: status.CurrentItemID, RepeatMode: status.RepeatMode}, nil
+}
+
+// items returns queue items of ids in the order of ids
+func (s *mediaSession) items(ctx context.Context, ids []int) ([]QueueItem, error) {
+	media, err := s.itemMedia(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]QueueItem, 0, len(ids))
+	for _, id := range ids {
+		m, ok := media[id]
+		if !ok {
+			continue
+		}
+		item := QueueItem{ItemID: id, ContentID: m.ContentId}
+		if m.Metadata != nil {
+			item.Title, item.Artist, item.AlbumName = m.Metadata.Title, m.Metadata.Artist, m.Metadata.AlbumName
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// itemMedia returns media of queue items of ids by item id
+func (s *mediaSession) itemMedia(ctx context.Context, ids []int) (map[int]mediaData, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	msg, err := s.channel.Request(ctx, &queueGetItemsCommand{
+		PayloadHeaders: castnet.PayloadHeaders{Type: "QUEUE_GET_ITEMS"},
+		MediaSessionID: s.media.MediaSessionID,
+		ItemIDs:        ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp queueItemsResponse
+	if err := json.Unmarshal([]byte(msg.GetPayloadUtf8()), &resp); err != nil {
+		return nil, err
+	}
+	media := make(map[int]mediaData, len(resp.Items))
+	for _, it := range resp.Items {
+		media[it.ItemID] = it.Media
+	}
+	return media, nil
+}
@@ -0,0 +1,114 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/barnybug/go-cast"
+)
+
+// ErrConnectionClosed is returned when connection to cast device was dropped and could not be re-dialed
+var ErrConnectionClosed = errors.New("homecast: connection closed")
+
+const (
+	// reconnectAttempts is how many times a dropped connection is re-dialed before giving up
+	reconnectAttempts = 5
+	// reconnectMaxDelay caps exponential backoff between re-dials
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// SetAutoReconnect enables or disables re-dialing dropped connections of cast device
+func (g *CastDevice) SetAutoReconnect(enabled bool) {
+	g.configure(func(s *deviceSettings) { s.autoReconnect = enabled })
+}
+
+// withReconnect runs f, and if it failed because connection was dropped, reconnects and runs f again
+func (g *CastDevice) withReconnect(ctx context.Context, f func() error) error {
+	gen := g.clientGeneration()
+	err := f()
+	if !isConnError(err) {
+		return err
+	}
+	if !g.currentSettings().autoReconnect {
+		return connError(err)
+	}
+	g.logf("[INFO] Connection lost: device=%s err=%v", g.Name, err)
+	if err := g.reconnect(ctx, gen); err != nil {
+		return connError(err)
+	}
+	return connError(f())
+}
+
+// connError wraps err with ErrConnectionClosed if it means connection to the device was dropped
+func connError(err error) error {
+	if !isConnError(err) || errors.Is(err, ErrConnectionClosed) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrConnectionClosed, err)
+}
+
+// clientGeneration returns how many times client of cast device was replaced by reconnect
+func (g *CastDevice) clientGeneration() uint64 {
+	g.clientMu.RLock()
+	defer g.clientMu.RUnlock()
+	return g.clientGen
+}
+
+// reconnect re-dials cast device with a new client, backing off exponentially between attempts.
+// It does nothing if the client was already replaced since generation gen, such as by another call
+// which failed on the same dropped connection.
+func (g *CastDevice) reconnect(ctx context.Context, gen uint64) error {
+	g.connMu.Lock()
+	defer g.connMu.Unlock()
+	if g.clientGeneration() != gen {
+		g.logf("[INFO] Already reconnected: device=%s", g.Name)
+		return nil
+	}
+
+	delay := time.Second
+	var err error
+	for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+		client := cast.NewClient(entryAddr(g.ServiceEntry), g.Port)
+		if err = client.Connect(ctx); err == nil {
+			g.clientMu.Lock()
+			old := g.client
+			g.client = client
+			g.clientGen++
+			g.clientMu.Unlock()
+			old.Close()
+			g.logf("[INFO] Reconnected: device=%s attempt=%d", g.Name, attempt)
+			return nil
+		}
+		g.logf("[ERROR] Failed to reconnect: device=%s attempt=%d err=%v", g.Name, attempt, err)
+		select {
+		case <-g.clk().After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if delay *= 2; delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+	return err
+}
+
+// isConnError reports whether err means established connection to the device was dropped.
+// Failures to dial, such as timeouts, are not, as dialing again won't help.
+func isConnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op != "read" && opErr.Op != "write" {
+		return false
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		opErr != nil || strings.Contains(err.Error(), "use of closed network connection")
+}
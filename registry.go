@@ -0,0 +1,89 @@
+package homecast
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/micro/mdns"
+)
+
+// Discoverer finds cast services and sends them to entries
+type Discoverer interface {
+	Discover(ctx context.Context, opts *DiscoverOptions, entries chan<- *mdns.ServiceEntry) error
+}
+
+var (
+	registryMu  sync.RWMutex
+	ttsRegistry = map[string]TTSProvider{
+		"google-translate": GoogleTranslate{Verify: true, Retries: translateRetries},
+	}
+	extractorRegistry = map[string]Extractor{
+		"yt-dlp": &YTDLP{},
+	}
+	discovererRegistry = map[string]Discoverer{
+		"mdns": mdnsDiscoverer{},
+	}
+)
+
+// RegisterTTS makes TTS provider available by name. It replaces the provider registered with the same name.
+func RegisterTTS(name string, p TTSProvider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	ttsRegistry[name] = p
+}
+
+// LookupTTS returns TTS provider registered by name
+func LookupTTS(name string) (TTSProvider, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok := ttsRegistry[name]
+	return p, ok
+}
+
+// RegisterExtractor makes media url extractor available by name, to be selected with WithExtractor.
+// yt-dlp is registered as "yt-dlp" by default.
+func RegisterExtractor(name string, e Extractor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	extractorRegistry[name] = e
+}
+
+// LookupExtractor returns media url extractor registered by name
+func LookupExtractor(name string) (Extractor, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	e, ok := extractorRegistry[name]
+	return e, ok
+}
+
+// RegisterDiscoverer adds discovery backend used by LookupAndConnect.
+// mDNS backend is registered as "mdns" by default, and can be removed with UnregisterDiscoverer.
+func RegisterDiscoverer(name string, d Discoverer) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	discovererRegistry[name] = d
+}
+
+// UnregisterDiscoverer removes discovery backend registered by name
+func UnregisterDiscoverer(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(discovererRegistry, name)
+}
+
+// discoverers returns registered discovery backends in name order
+func discoverers() []Discoverer {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(discovererRegistry))
+	for name := range discovererRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ds := make([]Discoverer, len(names))
+	for i, name := range names {
+		ds[i] = discovererRegistry[name]
+	}
+	return ds
+}
@@ -0,0 +1,290 @@
+package homecast
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// remoteCacheTimeout is how long requests of remote caches take by default
+const remoteCacheTimeout = 5 * time.Second
+
+// S3Cache is TTSCache storing audio as objects in a bucket of S3 compatible storage, such as Amazon S3 or MinIO,
+// so that several homecast instances share synthesized audio. Failures are logged and count as misses.
+type S3Cache struct {
+	// Endpoint is url of the storage, such as "https://s3.us-east-1.amazonaws.com" or "http://minio.local:9000".
+	// Buckets are addressed in path style.
+	Endpoint string
+	Bucket   string
+	// Prefix is prepended to keys of objects, such as "homecast/"
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is required for temporary credentials
+	SessionToken string
+	Region       string
+	// Timeout is how long each request takes. Default is 5 seconds.
+	Timeout time.Duration
+	// Logger logs failed requests. Default logs nothing.
+	Logger Logger
+}
+
+// Get returns audio of key
+func (c *S3Cache) Get(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout(c.Timeout))
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodGet, key, nil)
+	return readCachedAudio(c.Logger, resp, err)
+}
+
+// Put stores audio of key
+func (c *S3Cache) Put(key string, audio []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout(c.Timeout))
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodPut, key, audio)
+	checkCachePut(c.Logger, resp, err, http.StatusOK)
+}
+
+// do sends request of method on object of key with presigned url
+func (c *S3Cache) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + c.Bucket + "/" + c.Prefix + key + ".mp3"
+	creds := awsCredentials{c.AccessKeyID, c.SecretAccessKey, c.SessionToken, c.Region}
+	u.RawQuery = creds.presign(method, u.Host, u.EscapedPath(), "s3", "UNSIGNED-PAYLOAD", url.Values{}, remoteTimeout(c.Timeout), time.Now().UTC())
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "audio/mpeg")
+	}
+	return http.DefaultClient.Do(req.WithContext(ctx))
+}
+
+// HTTPCache is TTSCache backed by media server of another instance whose Cache is set,
+// so that instances in a home share audio synthesized by any of them.
+// Audio is added to the origin only if its CacheWritable is set. Failures are logged and count as misses.
+type HTTPCache struct {
+	// Origin is url of the media server of the other instance, such as "http://192.168.1.10:8090".
+	// Pin its port with MinPort and MaxPort.
+	Origin string
+	// Timeout is how long each request takes. Default is 5 seconds.
+	Timeout time.Duration
+	// Logger logs failed requests. Default logs nothing.
+	Logger Logger
+}
+
+// Get returns audio of key
+func (c *HTTPCache) Get(key string) ([]byte, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout(c.Timeout))
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodGet, key, nil)
+	return readCachedAudio(c.Logger, resp, err)
+}
+
+// Put stores audio of key on the origin
+func (c *HTTPCache) Put(key string, audio []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout(c.Timeout))
+	defer cancel()
+	resp, err := c.do(ctx, http.MethodPut, key, audio)
+	checkCachePut(c.Logger, resp, err, http.StatusNoContent)
+}
+
+func (c *HTTPCache) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimRight(c.Origin, "/")+"/media/"+key+".mp3", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "audio/mpeg")
+	}
+	return http.DefaultClient.Do(req.WithContext(ctx))
+}
+
+// readCachedAudio returns audio in response to GET of remote cache. Not found and failures are misses.
+func readCachedAudio(l Logger, resp *http.Response, err error) ([]byte, bool) {
+	if err != nil {
+		logf(l, "[ERROR] Failed to get cached audio: %v", err)
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false
+	}
+	if resp.StatusCode != http.StatusOK {
+		logf(l, "[ERROR] Failed to get cached audio: %s", resp.Status)
+		return nil, false
+	}
+	audio, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logf(l, "[ERROR] Failed to get cached audio: %v", err)
+		return nil, false
+	}
+	return audio, true
+}
+
+// checkCachePut logs failure of PUT to remote cache, answered with status on success
+func checkCachePut(l Logger, resp *http.Response, err error, status int) {
+	if err != nil {
+		logf(l, "[ERROR] Failed to put cached audio: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != status {
+		logf(l, "[ERROR] Failed to put cached audio: %s", resp.Status)
+	}
+}
+
+// RedisCache is TTSCache storing audio in Redis, so that several homecast instances share synthesized audio.
+// Failures are logged and count as misses.
+type RedisCache struct {
+	// Addr is "host:port" of Redis server
+	Addr     string
+	Password string
+	DB       int
+	// Prefix is prepended to keys. Default is "homecast:tts:".
+	Prefix string
+	// TTL is how long audio is kept. Zero means forever.
+	TTL time.Duration
+	// Timeout is how long each command takes. Default is 5 seconds.
+	Timeout time.Duration
+	// Logger logs failed commands. Default logs nothing.
+	Logger Logger
+}
+
+// Get returns audio of key
+func (c *RedisCache) Get(key string) ([]byte, bool) {
+	reply, err := c.do("GET", c.key(key))
+	if err != nil {
+		logf(c.Logger, "[ERROR] Failed to get cached audio: %v", err)
+		return nil, false
+	}
+	audio, ok := reply.([]byte)
+	return audio, ok
+}
+
+// Put stores audio of key
+func (c *RedisCache) Put(key string, audio []byte) {
+	args := []string{"SET", c.key(key), string(audio)}
+	if c.TTL > 0 {
+		args = append(args, "PX", strconv.FormatInt(c.TTL.Milliseconds(), 10))
+	}
+	if _, err := c.do(args...); err != nil {
+		logf(c.Logger, "[ERROR] Failed to put cached audio: %v", err)
+	}
+}
+
+func (c *RedisCache) key(key string) string {
+	if c.Prefix == "" {
+		return "homecast:tts:" + key
+	}
+	return c.Prefix + key
+}
+
+// do runs command on a new connection, as audio is rarely synthesized
+func (c *RedisCache) do(command ...string) (interface{}, error) {
+	timeout := remoteTimeout(c.Timeout)
+	conn, err := net.DialTimeout("tcp", c.Addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	commands := [][]string{command}
+	if c.DB != 0 {
+		commands = append([][]string{{"SELECT", strconv.Itoa(c.DB)}}, commands...)
+	}
+	if c.Password != "" {
+		commands = append([][]string{{"AUTH", c.Password}}, commands...)
+	}
+	w := bufio.NewWriter(conn)
+	for _, args := range commands {
+		writeRESP(w, args)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	var reply interface{}
+	for range commands {
+		if reply, err = readRESP(r); err != nil {
+			return nil, err
+		}
+	}
+	return reply, nil
+}
+
+// writeRESP writes args as a command of Redis serialization protocol
+func writeRESP(w *bufio.Writer, args []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+// readRESP reads a value of Redis serialization protocol.
+// Simple strings are string, integers are int64, bulk strings are []byte, nil is nil,
+// arrays are []interface{}, and errors are returned as error.
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("homecast: invalid redis reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("homecast: redis: %s", line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("homecast: invalid redis reply: %q", line)
+}
+
+func remoteTimeout(d time.Duration) time.Duration {
+	if d == 0 {
+		return remoteCacheTimeout
+	}
+	return d
+}
@@ -0,0 +1,116 @@
+package homecast
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestS3Cache(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("X-Amz-Signature") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			audio, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(audio)
+		}
+	}))
+	defer srv.Close()
+
+	c := &S3Cache{Endpoint: srv.URL, Bucket: "bucket", Prefix: "tts/", AccessKeyID: "id", SecretAccessKey: "secret", Region: "us-east-1"}
+	if _, ok := c.Get("key"); ok {
+		t.Fatal("Get() hit before Put")
+	}
+	c.Put("key", []byte("audio"))
+	if _, ok := objects["/bucket/tts/key.mp3"]; !ok {
+		t.Errorf("objects = %v, want /bucket/tts/key.mp3", objects)
+	}
+	if audio, ok := c.Get("key"); !ok || !bytes.Equal(audio, []byte("audio")) {
+		t.Errorf("Get() = %q, %v, want audio, true", audio, ok)
+	}
+}
+
+func TestRedisCache(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// fake redis server supporting commands used by RedisCache
+	var mu sync.Mutex
+	values := map[string][]byte{}
+	var commands []string
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+				for {
+					v, err := readRESP(r)
+					if err != nil {
+						return
+					}
+					args := v.([]interface{})
+					mu.Lock()
+					commands = append(commands, string(args[0].([]byte)))
+					switch string(args[0].([]byte)) {
+					case "GET":
+						if value, ok := values[string(args[1].([]byte))]; ok {
+							fmt.Fprintf(w, "$%d\r\n%s\r\n", len(value), value)
+						} else {
+							w.WriteString("$-1\r\n")
+						}
+					case "SET":
+						values[string(args[1].([]byte))] = args[2].([]byte)
+						w.WriteString("+OK\r\n")
+					default:
+						w.WriteString("+OK\r\n")
+					}
+					mu.Unlock()
+					w.Flush()
+				}
+			}()
+		}
+	}()
+
+	c := &RedisCache{Addr: l.Addr().String(), Password: "secret", DB: 1}
+	if _, ok := c.Get("key"); ok {
+		t.Fatal("Get() hit before Put")
+	}
+	c.Put("key", []byte("audio\r\nwith crlf"))
+	if audio, ok := c.Get("key"); !ok || string(audio) != "audio\r\nwith crlf" {
+		t.Errorf("Get() = %q, %v, want audio, true", audio, ok)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := values["homecast:tts:key"]; !ok {
+		t.Errorf("values = %v, want homecast:tts:key", values)
+	}
+	if len(commands) != 9 || commands[0] != "AUTH" || commands[1] != "SELECT" {
+		t.Errorf("commands = %v, want AUTH and SELECT before each", commands)
+	}
+}
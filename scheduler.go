@@ -0,0 +1,125 @@
+package homecast
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Scheduler runs jobs at scheduled times until stopped.
+// Each job waits for its schedule on its own goroutine, and runs on the pool when the time comes,
+// so that jobs due at the same time are bounded along with other fan-out operations.
+type Scheduler struct {
+	// Clock is used to wait for schedules. Default is the system clock.
+	Clock Clock
+	// Logger logs failures of reminders. Default logs nothing.
+	Logger Logger
+	// Pool runs jobs. DefaultPool is used when nil.
+	// Jobs fanning out on the same pool, such as by Broadcast, can wait for each other's slots when many are due at once,
+	// so give the scheduler its own pool when jobs do so.
+	Pool *Pool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler
+func NewScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{ctx: ctx, cancel: cancel}
+}
+
+// Daily runs f every day at hour:minute in local time. Call returned func to cancel the job.
+func (s *Scheduler) Daily(hour, minute int, f func(context.Context)) (cancel func()) {
+	return s.schedule(func(now time.Time) time.Time {
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}, f)
+}
+
+// Every runs f every interval. Call returned func to cancel the job.
+func (s *Scheduler) Every(interval time.Duration, f func(context.Context)) (cancel func()) {
+	return s.schedule(func(now time.Time) time.Time {
+		return now.Add(interval)
+	}, f)
+}
+
+// While runs f immediately and then every interval as long as cond returns true.
+// The job stops by itself once cond returns false. Call returned func to cancel it earlier.
+func (s *Scheduler) While(interval time.Duration, cond func(context.Context) bool, f func(context.Context)) (cancel func()) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		for ctx.Err() == nil && cond(ctx) {
+			s.run(ctx, f)
+			select {
+			case <-s.clk().After(interval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return cancel
+}
+
+// Remind speaks text on device every interval while cond returns true, such as
+// while a door is left open or a server is down. It stops by itself when cond clears.
+func (s *Scheduler) Remind(device Device, text, lang string, interval time.Duration, cond func(context.Context) bool) (cancel func()) {
+	return s.While(interval, cond, func(ctx context.Context) {
+		if err := device.Speak(ctx, text, lang); err != nil {
+			logf(s.Logger, "[ERROR] Failed to speak reminder: %v", err)
+		}
+	})
+}
+
+// Stop cancels all jobs and waits for running ones to return
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) clk() Clock {
+	if s.Clock == nil {
+		return realClock{}
+	}
+	return s.Clock
+}
+
+// run runs f on the pool and waits for it, so that runs of a job don't overlap
+func (s *Scheduler) run(ctx context.Context, f func(context.Context)) {
+	pool := s.Pool
+	if pool == nil {
+		pool = DefaultPool
+	}
+	done := make(chan struct{})
+	pool.Go(func() {
+		defer close(done)
+		f(ctx)
+	})
+	<-done
+}
+
+// schedule runs f at times returned by next until cancelled
+func (s *Scheduler) schedule(next func(now time.Time) time.Time, f func(context.Context)) func() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			now := s.clk().Now()
+			select {
+			case <-s.clk().After(next(now).Sub(now)):
+				s.run(ctx, f)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return cancel
+}
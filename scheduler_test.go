@@ -0,0 +1,53 @@
+package homecast
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSchedulerEvery(t *testing.T) {
+	clock := newFakeClock()
+	s := NewScheduler()
+	s.Clock = clock
+	defer s.Stop()
+
+	runs := make(chan time.Time, 1)
+	s.Every(time.Hour, func(context.Context) {
+		runs <- clock.Now()
+	})
+
+	start := clock.Now()
+	for i := 1; i <= 3; i++ {
+		clock.BlockUntil(t, 1)
+		clock.Advance(time.Hour)
+		select {
+		case at := <-runs:
+			if want := start.Add(time.Duration(i) * time.Hour); !at.Equal(want) {
+				t.Errorf("run %d at %v, want %v", i, at, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("run %d didn't happen", i)
+		}
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	clock := newFakeClock()
+	s := NewScheduler()
+	s.Clock = clock
+	defer s.Stop()
+
+	runs := make(chan struct{}, 1)
+	cancel := s.Every(time.Minute, func(context.Context) {
+		runs <- struct{}{}
+	})
+	clock.BlockUntil(t, 1)
+	cancel()
+	clock.Advance(time.Minute)
+	select {
+	case <-runs:
+		t.Error("cancelled job ran")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,85 @@
+package homecast
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// scrobbleTimeout bounds each call to Scrobbler
+const scrobbleTimeout = 10 * time.Second
+
+// Track is media played in a session, described by metadata given with WithMetadata
+type Track struct {
+	Device    *CastDevice
+	ContentID string
+	// Title, Artist and Album are taken from metadata keys "title", "artist" and "album"
+	Title, Artist, Album string
+	// Metadata is the whole metadata of the session
+	Metadata map[string]string
+	Started  time.Time
+	// Listened is how long the track was playing. It is zero in NowPlaying.
+	Listened time.Duration
+}
+
+// Scrobbler is notified of tracks played through this package, such as to record listening history
+type Scrobbler interface {
+	// NowPlaying is called when a track started
+	NowPlaying(ctx context.Context, t Track) error
+	// Scrobble is called when a track finished without error
+	Scrobble(ctx context.Context, t Track) error
+}
+
+type trackKey struct {
+	device    *CastDevice
+	contentID string
+}
+
+// Scrobble reports sessions started by Play and Speak to s. Call returned func to stop.
+func Scrobble(s Scrobbler) (stop func()) {
+	var mu sync.Mutex
+	started := map[trackKey]time.Time{}
+	return Subscribe(func(e Event) {
+		switch e := e.(type) {
+		case SessionStarted:
+			t := newTrack(e.Session, time.Now())
+			mu.Lock()
+			started[trackKey{t.Device, t.ContentID}] = t.Started
+			mu.Unlock()
+			go scrobble("now playing", t, s.NowPlaying)
+		case SessionEnded:
+			key := trackKey{e.Session.Device, e.Session.ContentID}
+			mu.Lock()
+			at, ok := started[key]
+			delete(started, key)
+			mu.Unlock()
+			if !ok || e.Session.Err != nil {
+				return
+			}
+			t := newTrack(e.Session, at)
+			t.Listened = time.Since(at)
+			go scrobble("scrobble", t, s.Scrobble)
+		}
+	})
+}
+
+func newTrack(session Session, started time.Time) Track {
+	return Track{
+		Device:    session.Device,
+		ContentID: session.ContentID,
+		Title:     session.Metadata["title"],
+		Artist:    session.Metadata["artist"],
+		Album:     session.Metadata["album"],
+		Metadata:  session.Metadata,
+		Started:   started,
+	}
+}
+
+func scrobble(name string, t Track, f func(context.Context, Track) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrobbleTimeout)
+	defer cancel()
+	if err := f(ctx, t); err != nil {
+		publish(ErrorEvent{Err: fmt.Errorf("homecast: failed to %s: content_id=%s: %w", name, t.ContentID, err)})
+	}
+}
@@ -0,0 +1,24 @@
+package homecast
+
+import (
+	"context"
+	"strings"
+)
+
+// Segment is a part of announcement spoken in its own language
+type Segment struct {
+	Text string
+	Lang string
+}
+
+// SpeakSegments speaks segments in a row as one announcement, such as a message mixing Japanese and English.
+// Each segment is synthesized separately and queued, preloading the next one to avoid gaps.
+// Like Speak, it follows volume settings of cast device, is recorded in history and takes options.
+func (g *CastDevice) SpeakSegments(ctx context.Context, segments []Segment, opts ...PlayOption) (err error) {
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+	defer g.audit(ctx, "speak_segments", strings.Join(texts, " "), &err)
+	return g.speak(ctx, segments, "", 0, opts)
+}
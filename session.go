@@ -0,0 +1,223 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// completionPollInterval is how often media status is checked while waiting for playback to finish
+const completionPollInterval = time.Second
+
+// ErrPlaybackFailed is returned when cast device failed to play loaded media
+var ErrPlaybackFailed = errors.New("homecast: playback failed")
+
+// Session is a media playback started by Play or Speak
+type Session struct {
+	// Device is the device playing the media. It is nil for SimulatedDevice.
+	Device    *CastDevice
+	ContentID string
+	// Metadata is given by WithMetadata to correlate the session with its origin
+	Metadata map[string]string
+	// Err is non-nil when playback failed
+	Err error
+}
+
+// PlayOption configures Play and Speak call
+type PlayOption func(*playOptions)
+
+type playOptions struct {
+	onComplete  func(Session)
+	retries     int
+	metadata    map[string]string
+	dryRun      bool
+	preflight   bool
+	title       string
+	caption     bool
+	wait        bool
+	pipeline    string
+	volume      *float64
+	contentType string
+	noAudit     bool
+	fit         time.Duration
+	voice       Voice
+	provider    string
+	extractor   string
+	streamType  string
+	// hooks are internal callbacks invoked on completion along with onComplete
+	hooks []func(Session)
+}
+
+func newPlayOptions(opts []PlayOption) playOptions {
+	var o playOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// OnComplete registers a callback invoked when the media finishes or errors
+func OnComplete(f func(Session)) PlayOption {
+	return func(o *playOptions) {
+		o.onComplete = f
+	}
+}
+
+// WithRetry retries loading media up to n times when the receiver reports a transient failure
+func WithRetry(n int) PlayOption {
+	return func(o *playOptions) {
+		o.retries = n
+	}
+}
+
+// WithMetadata attaches arbitrary key/value metadata to the session.
+// It is carried by Session passed to callbacks and events.
+func WithMetadata(metadata map[string]string) PlayOption {
+	return func(o *playOptions) {
+		o.metadata = metadata
+	}
+}
+
+// WithDryRun validates the call without playing anything, same as DryRun for one call
+func WithDryRun() PlayOption {
+	return func(o *playOptions) {
+		o.dryRun = true
+	}
+}
+
+// WithPreflight verifies the media url before loading it, failing fast with descriptive error
+// when it is unreachable, empty, or not audio. The device's own failure on such url is silent.
+func WithPreflight() PlayOption {
+	return func(o *playOptions) {
+		o.preflight = true
+	}
+}
+
+// WithTitle sets title of the media shown by devices with display
+func WithTitle(title string) PlayOption {
+	return func(o *playOptions) {
+		o.title = title
+	}
+}
+
+// WithCaption makes Speak show the spoken text as the title on devices with display
+func WithCaption() PlayOption {
+	return func(o *playOptions) {
+		o.caption = true
+	}
+}
+
+// WithWait makes Play and Speak block until the media has finished playing or ctx is done
+func WithWait() PlayOption {
+	return func(o *playOptions) {
+		o.wait = true
+	}
+}
+
+// WithContentType sets MIME type of the media. By default it is guessed from the url extension,
+// then from Content-Type of the response, and "audio/mp3" when both are unknown.
+func WithContentType(contentType string) PlayOption {
+	return func(o *playOptions) {
+		o.contentType = contentType
+	}
+}
+
+// WithStreamType sets stream type of the media, StreamBuffered or StreamLive. Default is StreamBuffered.
+func WithStreamType(streamType string) PlayOption {
+	return func(o *playOptions) {
+		o.streamType = streamType
+	}
+}
+
+// mediaMetadata returns metadata shown by devices with display, from WithTitle and
+// "title", "artist" and "album" keys of WithMetadata
+func (o *playOptions) mediaMetadata() *mediaMetadata {
+	m := &mediaMetadata{
+		MetadataType: genericMetadata,
+		Title:        o.metadata["title"],
+		Artist:       o.metadata["artist"],
+		AlbumName:    o.metadata["album"],
+	}
+	if o.title != "" {
+		m.Title = o.title
+	}
+	if m.Artist != "" || m.AlbumName != "" {
+		m.MetadataType = musicTrackMetadata
+	}
+	if m.Title == "" && m.MetadataType == genericMetadata {
+		return nil
+	}
+	return m
+}
+
+// withoutAudit skips auditing Play called for a command already audited
+func withoutAudit() PlayOption {
+	return func(o *playOptions) {
+		o.noAudit = true
+	}
+}
+
+func withHook(f func(Session)) PlayOption {
+	return func(o *playOptions) {
+		o.hooks = append(o.hooks, f)
+	}
+}
+
+// session returns Session of contentID on g with options applied
+func (o *playOptions) session(g *CastDevice, contentID string, err error) Session {
+	return Session{Device: g, ContentID: contentID, Metadata: o.metadata, Err: err}
+}
+
+// complete publishes end of session and invokes completion callback if registered
+func (o *playOptions) complete(session Session) {
+	publish(SessionEnded{session})
+	for _, hook := range o.hooks {
+		hook(session)
+	}
+	if o.onComplete != nil {
+		o.onComplete(session)
+	}
+}
+
+// wait blocks until media of contentIDs, loaded as a queue, finishes playing.
+// It returns MediaError if the receiver reports an error.
+func (s *mediaSession) wait(ctx context.Context, contentIDs ...string) error {
+	for {
+		status, err := s.status(ctx)
+		if err != nil {
+			return err
+		}
+		// The session has gone
+		if status == nil {
+			return nil
+		}
+		// Another sender loaded other media
+		if status.Media != nil && !contains(contentIDs, status.Media.ContentId) {
+			return ErrSessionTakenOver
+		}
+		if status.PlayerState == "IDLE" && status.IdleReason != "" {
+			switch status.IdleReason {
+			case "ERROR":
+				return &MediaError{Type: "ERROR"}
+			case "INTERRUPTED":
+				return ErrSessionTakenOver
+			}
+			return nil
+		}
+
+		select {
+		case <-s.clock.After(completionPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
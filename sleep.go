@@ -0,0 +1,58 @@
+package homecast
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	sleepFadeDuration = time.Minute
+	sleepFadeStep     = 2 * time.Second
+)
+
+// SleepTimer stops playback on cast device when d has elapsed.
+// The volume fades out over the last minute and is restored after playback stopped,
+// so that the next playback does not start silently.
+// It blocks until the timer elapses or ctx is cancelled.
+func (g *CastDevice) SleepTimer(ctx context.Context, d time.Duration) (err error) {
+	defer g.audit(ctx, "sleep_timer", d.String(), &err)
+	fade := sleepFadeDuration
+	if d < fade {
+		fade = d
+	}
+
+	select {
+	case <-g.clk().After(d - fade):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	original, err := g.volume(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// ctx may be already cancelled here
+		restoreCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := g.setVolume(restoreCtx, original); err != nil {
+			g.logf("[ERROR] Failed to restore volume: %v", err)
+		}
+	}()
+
+	g.logf("[INFO] Sleep timer fading out: %s", g.Name)
+	steps := int(fade / sleepFadeStep)
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-g.clk().After(sleepFadeStep):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := g.setVolume(ctx, original*float64(steps-i)/float64(steps)); err != nil {
+			return err
+		}
+	}
+
+	g.logf("[INFO] Sleep timer stopping playback: %s", g.Name)
+	return g.Stop(ctx)
+}
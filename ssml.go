@@ -0,0 +1,128 @@
+package homecast
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strings"
+)
+
+// Voice tunes synthesized speech. Zero values are defaults of TTS provider.
+type Voice struct {
+	// Name is voice of TTS provider, such as "en-US-Wavenet-D" for Cloud TTS or "Joanna" for Polly
+	Name string
+	// Rate is speaking rate relative to the normal speed, 1
+	Rate float64
+	// Pitch is change of pitch in semitones, from -20 to 20
+	Pitch float64
+}
+
+// SSMLSynthesizer is implemented by TTS providers which accept SSML and voice tuning
+type SSMLSynthesizer interface {
+	SynthesizeSSML(ctx context.Context, ssml, lang string, voice Voice) (*url.URL, error)
+}
+
+// WithVoice makes Speak and SpeakSSML synthesize speech with voice.
+// It takes effect with TTS providers implementing SSMLSynthesizer; others speak with their default voice.
+func WithVoice(v Voice) PlayOption {
+	return func(o *playOptions) {
+		o.voice = v
+	}
+}
+
+// SpeakSSML speaks SSML document on cast device, such as to emphasize words and insert pauses:
+//
+//	<speak>Dinner is <emphasis>ready</emphasis>.<break time="1s"/>Come downstairs.</speak>
+//
+// TTS providers not implementing SSMLSynthesizer speak its text without markup.
+// Unlike Speak, it is not split into chunks however long it is.
+func (g *CastDevice) SpeakSSML(ctx context.Context, ssml, lang string, opts ...PlayOption) (err error) {
+	defer g.audit(ctx, "speak_ssml", ssml, &err)
+	text, err := ssmlText(ssml)
+	if err != nil {
+		return err
+	}
+	return g.speak(ctx, []Segment{{Text: text, Lang: lang}}, ssml, 0, opts)
+}
+
+// voiceFor returns voice to speak text with, sped up to fit duration given by WithFitDuration
+func (o *playOptions) voiceFor(text string) Voice {
+	v := o.voice
+	if v.Rate == 0 {
+		v.Rate = 1
+	}
+	v.Rate *= o.speakingRate(text)
+	return v
+}
+
+// synthesizeVoice synthesizes ssml, or text if ssml is empty, by p with voice.
+// It degrades to text at the rate of voice if p doesn't support SSML.
+func (g *CastDevice) synthesizeVoice(ctx context.Context, p TTSProvider, text, ssml, lang string, voice Voice) (*url.URL, error) {
+	if ssml == "" && voice.Name == "" && voice.Pitch == 0 {
+		return g.synthesizeRate(ctx, p, text, lang, voice.Rate)
+	}
+	s, ok := p.(SSMLSynthesizer)
+	if !ok {
+		g.logf("[INFO] TTS provider doesn't support SSML, speak plain text")
+		return g.synthesizeRate(ctx, p, text, lang, voice.Rate)
+	}
+	if ssml == "" {
+		ssml = "<speak>" + escapeSSML(text) + "</speak>"
+	}
+	u, err := s.SynthesizeSSML(ctx, ssml, lang, voice)
+	return u, ttsError(err)
+}
+
+// ssmlText returns text of ssml without markup
+func ssmlText(ssml string) (string, error) {
+	var b strings.Builder
+	dec := xml.NewDecoder(strings.NewReader(ssml))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return strings.Join(strings.Fields(b.String()), " "), nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("homecast: invalid ssml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			if t.Name.Local == "break" {
+				b.WriteByte(' ')
+			}
+		}
+	}
+}
+
+// escapeSSML escapes text to be put in SSML document
+func escapeSSML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// withProsody wraps content of ssml's speak element in prosody of voice's rate and pitch
+func withProsody(ssml string, voice Voice) string {
+	var attrs []string
+	if voice.Rate != 0 && voice.Rate != 1 {
+		attrs = append(attrs, fmt.Sprintf(`rate="%d%%"`, int(voice.Rate*100)))
+	}
+	if voice.Pitch != 0 {
+		// Percent of frequency, as semitones are not accepted by every engine
+		attrs = append(attrs, fmt.Sprintf(`pitch="%+d%%"`, int((math.Pow(2, voice.Pitch/12)-1)*100)))
+	}
+	start, end := strings.Index(ssml, "<speak"), strings.LastIndex(ssml, "</speak>")
+	if len(attrs) == 0 || start < 0 || end < 0 {
+		return ssml
+	}
+	open := start + strings.Index(ssml[start:], ">") + 1
+	if open <= start || open > end {
+		return ssml
+	}
+	return ssml[:open] + "<prosody " + strings.Join(attrs, " ") + ">" + ssml[open:end] + "</prosody>" + ssml[end:]
+}
@@ -0,0 +1,65 @@
+package homecast
+
+import "testing"
+
+func TestSSMLText(t *testing.T) {
+	tests := []struct {
+		name    string
+		ssml    string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "<speak>Hello</speak>", "Hello", false},
+		{"markup", "<speak>Dinner is <emphasis>ready</emphasis>.</speak>", "Dinner is ready.", false},
+		{"break", `<speak>Hello<break time="1s"/>world</speak>`, "Hello world", false},
+		{"whitespace", "<speak>\n  Hello\n  world\n</speak>", "Hello world", false},
+		{"entity", "<speak>Tom &amp; Jerry</speak>", "Tom & Jerry", false},
+		{"unclosed", "<speak>Hello", "", true},
+		{"mismatched", "<speak>Hello</p>", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ssmlText(tt.ssml)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ssmlText(%q) error = %v, wantErr %v", tt.ssml, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ssmlText(%q) = %q, want %q", tt.ssml, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithProsody(t *testing.T) {
+	tests := []struct {
+		name  string
+		ssml  string
+		voice Voice
+		want  string
+	}{
+		{"default voice", "<speak>Hi</speak>", Voice{}, "<speak>Hi</speak>"},
+		{"normal rate", "<speak>Hi</speak>", Voice{Rate: 1}, "<speak>Hi</speak>"},
+		{"rate", "<speak>Hi</speak>", Voice{Rate: 1.5}, `<speak><prosody rate="150%">Hi</prosody></speak>`},
+		{"pitch up an octave", "<speak>Hi</speak>", Voice{Pitch: 12}, `<speak><prosody pitch="+100%">Hi</prosody></speak>`},
+		{"pitch down an octave", "<speak>Hi</speak>", Voice{Pitch: -12}, `<speak><prosody pitch="-50%">Hi</prosody></speak>`},
+		{"rate and pitch", "<speak>Hi</speak>", Voice{Rate: 0.8, Pitch: 12},
+			`<speak><prosody rate="80%" pitch="+100%">Hi</prosody></speak>`},
+		{"speak attributes", `<speak xml:lang="en-US">Hi</speak>`, Voice{Rate: 2},
+			`<speak xml:lang="en-US"><prosody rate="200%">Hi</prosody></speak>`},
+		{"no speak element", "Hi", Voice{Rate: 2}, "Hi"},
+		{"empty speak element", "<speak/>", Voice{Rate: 2}, "<speak/>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withProsody(tt.ssml, tt.voice); got != tt.want {
+				t.Errorf("withProsody(%q, %+v) = %q, want %q", tt.ssml, tt.voice, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeSSML(t *testing.T) {
+	if got, want := escapeSSML(`Tom & "Jerry" <3`), "Tom &amp; &#34;Jerry&#34; &lt;3"; got != want {
+		t.Errorf("escapeSSML() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/barnybug/go-cast/controllers"
+)
+
+// MediaStatus is a snapshot of what cast device is playing
+type MediaStatus struct {
+	// PlayerState is one of IDLE, PLAYING, PAUSED and BUFFERING
+	PlayerState string
+	// IdleReason tells why player is IDLE, such as FINISHED or CANCELLED
+	IdleReason string
+	ContentID  string
+	Position   time.Duration
+	Duration   time.Duration
+	// Volume is volume level of the device in [0, 1]
+	Volume float64
+	Muted  bool
+}
+
+// Status returns current player state of cast device. PlayerState is IDLE when nothing is loaded.
+func (g *CastDevice) Status(ctx context.Context) (*MediaStatus, error) {
+	st, err := g.volumeStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, err := g.openMedia(ctx, false)
+	if errors.Is(err, ErrNoMediaSession) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	if err := s.fillStatus(ctx, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// volumeStatus returns status of cast device with only volume filled, as nothing is loaded
+func (g *CastDevice) volumeStatus(ctx context.Context) (*MediaStatus, error) {
+	st := &MediaStatus{PlayerState: "IDLE"}
+	var v *controllers.Volume
+	err := g.withReconnect(ctx, func() (err error) {
+		v, err = g.castClient().Receiver().GetVolume(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if v != nil && v.Level != nil {
+		st.Volume = *v.Level
+	}
+	if v != nil && v.Muted != nil {
+		st.Muted = *v.Muted
+	}
+	return st, nil
+}
+
+// fillStatus fills st with player state of media session
+func (s *mediaSession) fillStatus(ctx context.Context, st *MediaStatus) error {
+	status, err := s.status(ctx)
+	if err != nil || status == nil {
+		return err
+	}
+	p := newProgress(status, 0)
+	st.PlayerState = p.PlayerState
+	st.IdleReason = status.IdleReason
+	st.ContentID = p.ContentID
+	st.Position = p.Position
+	st.Duration = p.Duration
+	return nil
+}
@@ -0,0 +1,17 @@
+package homecast
+
+import (
+	"context"
+	"io"
+)
+
+// PlayReader streams audio read from r, such as piped ffmpeg output or a local TTS engine, to cast device
+// as live media of contentType. It is served by DefaultMediaServer.
+func (g *CastDevice) PlayReader(ctx context.Context, r io.Reader, contentType string, opts ...PlayOption) error {
+	u, err := DefaultMediaServer.ServeReader(r, contentType)
+	if err != nil {
+		return err
+	}
+	opts = append([]PlayOption{WithContentType(contentType), WithStreamType(StreamLive)}, opts...)
+	return g.Play(ctx, u, opts...)
+}
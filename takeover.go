@@ -0,0 +1,42 @@
+package homecast
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrSessionTakenOver is returned when another sender, such as a phone app or a voice command,
+// replaced media played by this package
+var ErrSessionTakenOver = errors.New("homecast: session taken over by another sender")
+
+// TakeoverPolicy decides what Play does when another sender takes over cast device.
+// SessionTakenOver is published in either case.
+type TakeoverPolicy int
+
+const (
+	// TakeoverYield gives up the session. It ends with ErrSessionTakenOver.
+	TakeoverYield TakeoverPolicy = iota
+	// TakeoverReassert loads the media again from the beginning after a delay, once per Play
+	TakeoverReassert
+)
+
+// SetTakeoverPolicy sets what to do when another sender takes over cast device during Play or Speak.
+// delay is how long to wait before reasserting with TakeoverReassert. Default is TakeoverYield.
+func (g *CastDevice) SetTakeoverPolicy(p TakeoverPolicy, delay time.Duration) {
+	g.configure(func(s *deviceSettings) { s.takeover, s.takeoverDelay = p, delay })
+}
+
+// setLoaded records content ids this package loaded on cast device last
+func (g *CastDevice) setLoaded(contentIDs ...string) {
+	g.loadedMu.Lock()
+	defer g.loadedMu.Unlock()
+	g.loaded = contentIDs
+}
+
+// supersedes reports whether media loaded on cast device last by this package is other than contentID,
+// so that replacement of contentID is not a takeover by another sender
+func (g *CastDevice) supersedes(contentID string) bool {
+	g.loadedMu.Lock()
+	defer g.loadedMu.Unlock()
+	return !contains(g.loaded, contentID)
+}
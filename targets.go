@@ -0,0 +1,62 @@
+package homecast
+
+import (
+	"context"
+	"strings"
+)
+
+var aliasRegistry = map[string][]string{}
+
+// RegisterAlias names a mix of speaker groups, devices and other aliases by their friendly names,
+// such as a room covered by a group and a standalone speaker
+func RegisterAlias(alias string, names ...string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	aliasRegistry[strings.ToLower(alias)] = names
+}
+
+// UnregisterAlias removes alias registered by RegisterAlias
+func UnregisterAlias(alias string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(aliasRegistry, strings.ToLower(alias))
+}
+
+// LookupAlias returns names registered for alias
+func LookupAlias(alias string) ([]string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names, ok := aliasRegistry[strings.ToLower(alias)]
+	return names, ok
+}
+
+// SelectTargets returns devices whose friendly names are given in names, expanding aliases.
+// A device which is a member of a selected group or stereo pair is skipped, so that it speaks only once.
+func SelectTargets(ctx context.Context, devices []*CastDevice, names ...string) []*CastDevice {
+	wanted := map[string]bool{}
+	expandAliases(names, wanted, map[string]bool{})
+
+	var targets []*CastDevice
+	for _, device := range devices {
+		if wanted[strings.ToLower(device.FriendlyName())] {
+			targets = append(targets, device)
+		}
+	}
+	return DedupeTargets(ctx, targets)
+}
+
+// expandAliases adds names to wanted, replacing aliases with names they reference
+func expandAliases(names []string, wanted, seen map[string]bool) {
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if refs, ok := LookupAlias(name); ok {
+			expandAliases(refs, wanted, seen)
+			continue
+		}
+		wanted[key] = true
+	}
+}
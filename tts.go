@@ -0,0 +1,262 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TTSProvider synthesizes speech of text and returns url of the sound playable by cast device
+type TTSProvider interface {
+	Synthesize(ctx context.Context, text, lang string) (*url.URL, error)
+}
+
+// RateSynthesizer is implemented by TTS providers which can change speaking rate.
+// rate is relative to the normal speed, 1.
+type RateSynthesizer interface {
+	SynthesizeRate(ctx context.Context, text, lang string, rate float64) (*url.URL, error)
+}
+
+// StreamSynthesizer is implemented by TTS providers which stream audio while synthesizing it,
+// so that playback of long text starts before its synthesis finishes
+type StreamSynthesizer interface {
+	SynthesizeStream(ctx context.Context, text, lang string) (audio io.ReadCloser, contentType string, err error)
+}
+
+// TextLimiter is implemented by TTS providers which can't speak text longer than MaxTextLength characters
+type TextLimiter interface {
+	MaxTextLength() int
+}
+
+// TextTooLongError is returned when text exceeds the limit of TTS provider and splitting is disabled
+type TextTooLongError struct {
+	Length int
+	Max    int
+}
+
+func (e *TextTooLongError) Error() string {
+	return fmt.Sprintf("homecast: text too long for tts: %d characters, max %d", e.Length, e.Max)
+}
+
+// ErrTTSThrottled is returned when the TTS endpoint rejects requests due to rate limit
+var ErrTTSThrottled = errors.New("homecast: tts endpoint throttled")
+
+// ErrTTSUnavailable is returned when TTS provider failed to synthesize speech, such as due to network failure.
+// The cause, such as ErrTTSThrottled, is matched too.
+var ErrTTSUnavailable = errors.New("homecast: tts unavailable")
+
+// ErrUnknownProvider is returned when TTS provider given by WithProvider is not registered
+var ErrUnknownProvider = errors.New("homecast: unknown tts provider")
+
+// WithProvider makes Speak synthesize speech with TTS provider registered by name with RegisterTTS,
+// instead of the one set by SetTTS, such as a premium voice for a morning briefing
+func WithProvider(name string) PlayOption {
+	return func(o *playOptions) {
+		o.provider = name
+	}
+}
+
+// translateRetries is how many times the default TTS provider retries when throttled
+const translateRetries = 2
+
+// translateClients are values of client parameter accepted by translate_tts
+var translateClients = []string{"tw-ob", "gtx"}
+
+// GoogleTranslate is TTSProvider using text-to-speech of Google Translate.
+// NOTE: it seems to be unofficial.
+// Throttling is detected only with Verify. Cast devices without TTS provider set, and the provider
+// registered as "google-translate", verify and retry; a zero GoogleTranslate returns urls without fetching them.
+type GoogleTranslate struct {
+	// Verify makes Synthesize fetch the sound before returning its url, to detect
+	// rate limit (429 or captcha page) which cast device would fail on silently.
+	// Throttled requests fail with ErrTTSThrottled.
+	Verify bool
+	// Retries is how many times to retry with backoff and another client parameter when throttled.
+	// It takes effect with Verify.
+	Retries int
+	// BaseURL replaces https://translate.google.com/translate_tts, such as a self-hosted proxy
+	// or a regional endpoint
+	BaseURL string
+	// Params are extra query parameters added to every request
+	Params url.Values
+	// Logger logs retries of throttled requests. Default logs nothing.
+	Logger Logger
+	// Clock is used to wait for backoff of retries. Default is the system clock.
+	Clock Clock
+}
+
+// Synthesize returns url of translate_tts for text
+func (t GoogleTranslate) Synthesize(ctx context.Context, text, lang string) (*url.URL, error) {
+	if !t.Verify {
+		return t.url(translateClients[0], text, lang)
+	}
+
+	for attempt := 0; ; attempt++ {
+		u, err := t.url(translateClients[attempt%len(translateClients)], text, lang)
+		if err != nil {
+			return nil, err
+		}
+		err = checkThrottled(ctx, u)
+		if err != ErrTTSThrottled || attempt >= t.Retries {
+			if err != nil {
+				return nil, err
+			}
+			return u, nil
+		}
+
+		// Exponential backoff with jitter, so that devices retrying together spread out
+		backoff := time.Duration(1<<uint(attempt))*time.Second + time.Duration(rand.Int63n(int64(time.Second)))
+		logf(t.Logger, "[INFO] TTS throttled, retry in %s", backoff)
+		select {
+		case <-t.clk().After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (t GoogleTranslate) clk() Clock {
+	if t.Clock == nil {
+		return realClock{}
+	}
+	return t.Clock
+}
+
+func (t GoogleTranslate) url(client, text, lang string) (*url.URL, error) {
+	u := &url.URL{
+		Scheme: "https",
+		Host:   "translate.google.com",
+		Path:   "/translate_tts",
+	}
+	if t.BaseURL != "" {
+		var err error
+		if u, err = url.Parse(t.BaseURL); err != nil {
+			return nil, err
+		}
+	}
+	var params string
+	if len(t.Params) > 0 {
+		params = "&" + t.Params.Encode()
+	}
+
+	// Build the query directly; this runs on every announcement
+	q, tl := url.QueryEscape(text), url.QueryEscape(lang)
+	var b strings.Builder
+	b.Grow(len(u.RawQuery) + len("&client=&ie=UTF-8&q=&tl=") + len(client) + len(q) + len(tl) + len(params))
+	if u.RawQuery != "" {
+		b.WriteString(u.RawQuery)
+		b.WriteByte('&')
+	}
+	b.WriteString("client=")
+	b.WriteString(client)
+	b.WriteString("&ie=UTF-8&q=")
+	b.WriteString(q)
+	b.WriteString("&tl=")
+	b.WriteString(tl)
+	b.WriteString(params)
+
+	u.RawQuery = b.String()
+	return u, nil
+}
+
+// checkThrottled fetches the first byte of u and returns ErrTTSThrottled if it is rate limited
+func checkThrottled(ctx context.Context, u *url.URL) error {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	// Captcha is served as html page after redirect to /sorry/
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable ||
+		strings.HasPrefix(resp.Request.URL.Path, "/sorry/") ||
+		strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return ErrTTSThrottled
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("homecast: tts endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// MaxTextLength returns length which translate_tts speaks without truncation
+func (GoogleTranslate) MaxTextLength() int {
+	return translateMaxLength
+}
+
+// SetTTS sets TTS provider used by Speak. Default is GoogleTranslate.
+func (g *CastDevice) SetTTS(p TTSProvider) {
+	g.configure(func(s *deviceSettings) { s.tts = p })
+}
+
+// SetSplitText sets whether Speak splits text longer than TTS provider's limit into chunks.
+// When disabled, such text fails with TextTooLongError. Default is enabled.
+func (g *CastDevice) SetSplitText(enabled bool) {
+	g.configure(func(s *deviceSettings) { s.noSplit = !enabled })
+}
+
+// ttsProvider returns TTS provider of cast device
+func (g *CastDevice) ttsProvider() TTSProvider {
+	if p := g.currentSettings().tts; p != nil {
+		return p
+	}
+	return GoogleTranslate{Verify: true, Retries: translateRetries, Logger: g.logger, Clock: g.clk()}
+}
+
+// ttsFor returns TTS provider selected by WithProvider, or the one of cast device
+func (g *CastDevice) ttsFor(o playOptions) (TTSProvider, error) {
+	if o.provider == "" {
+		return g.ttsProvider(), nil
+	}
+	p, ok := LookupTTS(o.provider)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, o.provider)
+	}
+	return p, nil
+}
+
+// maxTextLength returns text length limit of TTS provider, or 0 if unlimited
+func maxTextLength(p TTSProvider) int {
+	if l, ok := p.(TextLimiter); ok {
+		return l.MaxTextLength()
+	}
+	return 0
+}
+
+// synthesize converts text to sound url with device's TTS provider
+func (g *CastDevice) synthesize(ctx context.Context, text, lang string) (*url.URL, error) {
+	return g.synthesizeRate(ctx, g.ttsProvider(), text, lang, 1)
+}
+
+// synthesizeRate synthesizes text by p spoken at rate, or at normal speed if p can't change it
+func (g *CastDevice) synthesizeRate(ctx context.Context, p TTSProvider, text, lang string, rate float64) (*url.URL, error) {
+	if rate != 1 {
+		if r, ok := p.(RateSynthesizer); ok {
+			u, err := r.SynthesizeRate(ctx, text, lang, rate)
+			return u, ttsError(err)
+		}
+		g.logf("[INFO] TTS provider can't change speaking rate, speak at normal speed")
+	}
+	u, err := p.Synthesize(ctx, text, lang)
+	return u, ttsError(err)
+}
+
+// ttsError wraps failure of TTS provider with ErrTTSUnavailable, except cancellation by caller
+func ttsError(err error) error {
+	if err == nil || isContextError(err) || errors.Is(err, ErrTTSUnavailable) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrTTSUnavailable, err)
+}
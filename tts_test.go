@@ -0,0 +1,46 @@
+package homecast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGoogleTranslateBackoff(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte{0})
+	}))
+	defer srv.Close()
+
+	clock := newFakeClock()
+	tts := GoogleTranslate{Verify: true, Retries: 1, BaseURL: srv.URL, Clock: clock}
+	done := make(chan error, 1)
+	go func() {
+		_, err := tts.Synthesize(context.Background(), "hello", "en")
+		done <- err
+	}()
+
+	// The first retry waits 1 second plus jitter under a second
+	clock.BlockUntil(t, 1)
+	clock.Advance(2 * time.Second)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Synthesize: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Synthesize didn't retry after backoff")
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
@@ -0,0 +1,323 @@
+package homecast
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TTSCache stores synthesized audio by key
+type TTSCache interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, audio []byte)
+}
+
+// CachedTTS is TTSProvider which caches audio synthesized by Provider, so that repeated phrases
+// are served locally without requesting the TTS endpoint every time.
+// Audio is keyed by a hash of text, language and voice, and served by the hash with ServeStable
+// so that cast devices can reuse audio they fetched before. With Cache set on the media server,
+// it keeps serving the hash after the url expires, and to HTTPCache of other instances.
+type CachedTTS struct {
+	Provider TTSProvider
+	Cache    TTSCache
+	// Server serves cached audio. DefaultMediaServer is used when nil.
+	Server *MediaServer
+}
+
+// Synthesize returns url of cached audio of text, synthesizing it on miss.
+// If Provider implements StreamSynthesizer, audio is cached as it is streamed to the device.
+func (t *CachedTTS) Synthesize(ctx context.Context, text, lang string) (*url.URL, error) {
+	key := ttsCacheKey(text, lang, Voice{})
+	s, ok := t.Provider.(StreamSynthesizer)
+	if !ok {
+		return t.synthesize(ctx, key, func() (*url.URL, error) {
+			return t.Provider.Synthesize(ctx, text, lang)
+		})
+	}
+	if _, ok := t.Cache.Get(key); ok {
+		return t.synthesize(ctx, key, nil)
+	}
+	audio, contentType, err := s.SynthesizeStream(ctx, text, lang)
+	if err != nil {
+		return nil, err
+	}
+	return t.server().ServeReader(&cachingReader{ReadCloser: audio, put: func(b []byte) { t.Cache.Put(key, b) }}, contentType)
+}
+
+// SynthesizeRate returns url of cached audio of text spoken at rate,
+// or at normal speed if Provider can't change speaking rate
+func (t *CachedTTS) SynthesizeRate(ctx context.Context, text, lang string, rate float64) (*url.URL, error) {
+	r, ok := t.Provider.(RateSynthesizer)
+	if !ok {
+		return t.Synthesize(ctx, text, lang)
+	}
+	return t.synthesize(ctx, ttsCacheKey(text, lang, Voice{Rate: rate}), func() (*url.URL, error) {
+		return r.SynthesizeRate(ctx, text, lang, rate)
+	})
+}
+
+// SynthesizeSSML returns url of cached audio of ssml with voice,
+// or of its text without markup if Provider doesn't support SSML
+func (t *CachedTTS) SynthesizeSSML(ctx context.Context, ssml, lang string, voice Voice) (*url.URL, error) {
+	s, ok := t.Provider.(SSMLSynthesizer)
+	if !ok {
+		text, err := ssmlText(ssml)
+		if err != nil {
+			return nil, err
+		}
+		if voice.Rate == 0 {
+			voice.Rate = 1
+		}
+		return t.SynthesizeRate(ctx, text, lang, voice.Rate)
+	}
+	return t.synthesize(ctx, ttsCacheKey(ssml, lang, voice), func() (*url.URL, error) {
+		return s.SynthesizeSSML(ctx, ssml, lang, voice)
+	})
+}
+
+// MaxTextLength returns text length limit of Provider, or 0 if unlimited
+func (t *CachedTTS) MaxTextLength() int {
+	if l, ok := t.Provider.(TextLimiter); ok {
+		return l.MaxTextLength()
+	}
+	return 0
+}
+
+func (t *CachedTTS) synthesize(ctx context.Context, key string, synthesize func() (*url.URL, error)) (*url.URL, error) {
+	audio, ok := t.Cache.Get(key)
+	if !ok {
+		u, err := synthesize()
+		if err != nil {
+			return nil, err
+		}
+		if audio, err = fetchAudio(ctx, u); err != nil {
+			return nil, err
+		}
+		t.Cache.Put(key, audio)
+	}
+	return t.server().ServeStable(key, audio, "audio/mpeg")
+}
+
+func (t *CachedTTS) server() *MediaServer {
+	if t.Server == nil {
+		return DefaultMediaServer
+	}
+	return t.Server
+}
+
+// cachingReader records audio read through it, and puts it to cache once read to the end
+type cachingReader struct {
+	io.ReadCloser
+	buf bytes.Buffer
+	put func([]byte)
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.Write(p[:n])
+	if err == io.EOF {
+		r.put(r.buf.Bytes())
+	}
+	return n, err
+}
+
+// fetchAudio downloads synthesized audio at u
+func fetchAudio(ctx context.Context, u *url.URL) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("homecast: tts endpoint returned %s", resp.Status)
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return nil, ErrTTSThrottled
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// isTTSCacheKey reports whether key is one returned by ttsCacheKey, so that it is safe to look up caches with
+func isTTSCacheKey(key string) bool {
+	_, err := hex.DecodeString(key)
+	return err == nil && len(key) == sha256.Size*2
+}
+
+// ttsCacheKey returns cache key of text spoken in lang with voice
+func ttsCacheKey(text, lang string, voice Voice) string {
+	if voice.Rate == 1 {
+		voice.Rate = 0
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%g\x00%g", text, lang, voice.Name, voice.Rate, voice.Pitch)))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryCache is TTSCache in memory, evicting least recently used audio over the size limit
+type MemoryCache struct {
+	// MaxBytes limits total size of audio. Zero means unlimited.
+	MaxBytes int
+	// TTL is how long audio is kept. Zero means forever.
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     list.List
+	size    int
+}
+
+type memoryCacheEntry struct {
+	key     string
+	audio   []byte
+	expires time.Time
+}
+
+// Get returns audio of key
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*memoryCacheEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e.audio, true
+}
+
+// Put stores audio of key
+func (c *MemoryCache) Put(key string, audio []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+	}
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	e := &memoryCacheEntry{key: key, audio: audio}
+	if c.TTL > 0 {
+		e.expires = time.Now().Add(c.TTL)
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	c.size += len(audio)
+	for c.MaxBytes > 0 && c.size > c.MaxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *MemoryCache) remove(el *list.Element) {
+	e := c.lru.Remove(el).(*memoryCacheEntry)
+	delete(c.entries, e.key)
+	c.size -= len(e.audio)
+}
+
+// DiskCache is TTSCache storing audio as files in Dir, so that it survives restart.
+// Oldest files are removed over the size limit.
+type DiskCache struct {
+	Dir string
+	// MaxBytes limits total size of files. Zero means unlimited.
+	MaxBytes int64
+	// TTL is how long audio is kept since it was last used. Zero means forever.
+	TTL time.Duration
+
+	mu sync.Mutex
+}
+
+// Get returns audio of key
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if c.TTL > 0 && time.Since(info.ModTime()) > c.TTL {
+		os.Remove(path)
+		return nil, false
+	}
+	audio, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	// Touch so that recently used files are removed last
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return audio, true
+}
+
+// Put stores audio of key. Failure to write is ignored, as it only costs another synthesis.
+func (c *DiskCache) Put(key string, audio []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return
+	}
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, audio, 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, c.path(key)); err != nil {
+		os.Remove(tmp)
+		return
+	}
+	c.evict()
+}
+
+// evict removes expired files, and oldest ones while total size exceeds the limit
+func (c *DiskCache) evict() {
+	files, err := filepath.Glob(filepath.Join(c.Dir, "*.mp3"))
+	if err != nil {
+		return
+	}
+	infos := make([]os.FileInfo, 0, len(files))
+	var size int64
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if c.TTL > 0 && time.Since(info.ModTime()) > c.TTL {
+			os.Remove(file)
+			continue
+		}
+		infos = append(infos, info)
+		size += info.Size()
+	}
+	if c.MaxBytes <= 0 {
+		return
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ModTime().Before(infos[j].ModTime()) })
+	for _, info := range infos {
+		if size <= c.MaxBytes {
+			return
+		}
+		if os.Remove(filepath.Join(c.Dir, info.Name())) == nil {
+			size -= info.Size()
+		}
+	}
+}
+
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.Dir, key+".mp3")
+}
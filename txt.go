@@ -0,0 +1,125 @@
+package homecast
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CastTXT is key/value pairs of TXT record advertised by cast device
+type CastTXT map[string]string
+
+// ParseCastTXT parses TXT record fields of cast service.
+// Keys are lower-cased, and only the first occurrence of a key is kept as RFC 6763 requires.
+// Backslash escapes such as `\"` or `\032` are unescaped. A field without '=' is stored with empty value.
+func ParseCastTXT(fields []string) CastTXT {
+	txt := make(CastTXT, len(fields))
+	for _, field := range fields {
+		field = unescapeTXT(field)
+		key, value := field, ""
+		if i := strings.IndexByte(field, '='); i >= 0 {
+			key, value = field[:i], field[i+1:]
+		}
+		key = strings.ToLower(key)
+		if key == "" {
+			continue
+		}
+		if _, ok := txt[key]; !ok {
+			txt[key] = value
+		}
+	}
+	return txt
+}
+
+// unescapeTXT resolves `\X` and decimal `\DDD` escapes
+func unescapeTXT(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		if i+3 < len(s) && isDigits(s[i+1:i+4]) {
+			if n, err := strconv.Atoi(s[i+1 : i+4]); err == nil && n < 256 {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		i++
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// castGroupModel is model name advertised by speaker groups
+const castGroupModel = "Google Cast Group"
+
+// TXT returns parsed TXT record of cast device. It is empty for devices not found by discovery.
+func (g *CastDevice) TXT() CastTXT {
+	return g.txt
+}
+
+// ID returns unique id of cast device
+func (g *CastDevice) ID() string {
+	return g.txt.ID()
+}
+
+// Model returns model name of cast device, such as "Google Home Mini"
+func (g *CastDevice) Model() string {
+	return g.txt.Model()
+}
+
+// FriendlyName returns the name given to cast device by its user, such as "Kitchen speaker"
+func (g *CastDevice) FriendlyName() string {
+	return g.txt.FriendlyName()
+}
+
+// IsGroup reports whether cast device is a speaker group. Media played on a group is played on all its members.
+func (g *CastDevice) IsGroup() bool {
+	return g.txt.IsGroup()
+}
+
+// ID returns unique id of the device
+func (t CastTXT) ID() string {
+	return t["id"]
+}
+
+// Model returns model name of the device, such as "Google Home"
+func (t CastTXT) Model() string {
+	return t["md"]
+}
+
+// FriendlyName returns the name given to the device by its user
+func (t CastTXT) FriendlyName() string {
+	return t["fn"]
+}
+
+// IsGroup reports whether the device is a speaker group
+func (t CastTXT) IsGroup() bool {
+	return t.Model() == castGroupModel
+}
+
+// ReceiverStatus returns status text of the running receiver app
+func (t CastTXT) ReceiverStatus() string {
+	return t["rs"]
+}
+
+// Capabilities returns capability bit mask of the device, or 0 when unknown
+func (t CastTXT) Capabilities() int {
+	ca, _ := strconv.Atoi(t["ca"])
+	return ca
+}
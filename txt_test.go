@@ -0,0 +1,45 @@
+package homecast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCastTXT(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		want   CastTXT
+	}{
+		{"empty", nil, CastTXT{}},
+		{"pairs", []string{"id=abc", "fn=Kitchen speaker", "md=Google Home"},
+			CastTXT{"id": "abc", "fn": "Kitchen speaker", "md": "Google Home"}},
+		{"key lower-cased", []string{"FN=Kitchen"}, CastTXT{"fn": "Kitchen"}},
+		{"first occurrence kept", []string{"fn=first", "FN=second"}, CastTXT{"fn": "first"}},
+		{"value with equals", []string{"rs=a=b"}, CastTXT{"rs": "a=b"}},
+		{"without equals", []string{"flag"}, CastTXT{"flag": ""}},
+		{"empty key", []string{"=value"}, CastTXT{}},
+		{"escaped quote", []string{`fn=Living \"room\"`}, CastTXT{"fn": `Living "room"`}},
+		{"decimal escape", []string{`fn=Living\032room`}, CastTXT{"fn": "Living room"}},
+		{"escaped backslash", []string{`fn=a\\b`}, CastTXT{"fn": `a\b`}},
+		{"trailing backslash", []string{`fn=a\`}, CastTXT{"fn": `a\`}},
+		{"out of range decimal", []string{`fn=\999`}, CastTXT{"fn": "999"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseCastTXT(tt.fields); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCastTXT(%q) = %v, want %v", tt.fields, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCastTXTAccessors(t *testing.T) {
+	txt := ParseCastTXT([]string{"id=abc", "fn=Upstairs", "md=Google Cast Group", "ca=2052"})
+	if txt.ID() != "abc" || txt.FriendlyName() != "Upstairs" || !txt.IsGroup() || txt.Capabilities() != 2052 {
+		t.Errorf("unexpected accessors of %v", txt)
+	}
+	if ParseCastTXT([]string{"ca=unknown"}).Capabilities() != 0 {
+		t.Error("Capabilities of invalid ca should be 0")
+	}
+}
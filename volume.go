@@ -0,0 +1,111 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/barnybug/go-cast/controllers"
+)
+
+// ErrVolumeOutOfRange is returned when given volume is out of range
+var ErrVolumeOutOfRange = errors.New("homecast: volume out of range")
+
+// VolumePolicy decides how SetVolume handles out of range values
+type VolumePolicy int
+
+const (
+	// VolumeReject returns ErrVolumeOutOfRange for out of range values
+	VolumeReject VolumePolicy = iota
+	// VolumeClamp clamps out of range values into the range
+	VolumeClamp
+)
+
+// SetVolumePolicy sets how SetVolume handles out of range values. Default is VolumeReject.
+func (g *CastDevice) SetVolumePolicy(p VolumePolicy) {
+	g.configure(func(s *deviceSettings) { s.volPolicy = p })
+}
+
+// SetVolume changes volume level of cast device. level is from 0 to 1.
+func (g *CastDevice) SetVolume(ctx context.Context, level float64) (err error) {
+	defer g.audit(ctx, "set_volume", fmt.Sprint(level), &err)
+	level, err = g.checkVolume(level, 0, 1)
+	if err != nil {
+		return err
+	}
+	return g.setVolume(ctx, level)
+}
+
+// SetVolumePercent changes volume level of cast device. percent is from 0 to 100.
+func (g *CastDevice) SetVolumePercent(ctx context.Context, percent int) (err error) {
+	defer g.audit(ctx, "set_volume", fmt.Sprintf("%d%%", percent), &err)
+	p, err := g.checkVolume(float64(percent), 0, 100)
+	if err != nil {
+		return err
+	}
+	return g.setVolume(ctx, p/100)
+}
+
+// GetVolume returns volume level of cast device, from 0 to 1
+func (g *CastDevice) GetVolume(ctx context.Context) (float64, error) {
+	return g.volume(ctx)
+}
+
+// Mute mutes or unmutes cast device
+func (g *CastDevice) Mute(ctx context.Context, muted bool) (err error) {
+	defer g.audit(ctx, "mute", fmt.Sprint(muted), &err)
+	return g.withReconnect(ctx, func() error {
+		_, err := g.castClient().Receiver().SetVolume(ctx, &controllers.Volume{Muted: &muted})
+		return err
+	})
+}
+
+// checkVolume validates v is in [min, max] according to volume policy
+func (g *CastDevice) checkVolume(v, min, max float64) (float64, error) {
+	if math.IsNaN(v) {
+		return 0, fmt.Errorf("%w: NaN", ErrVolumeOutOfRange)
+	}
+	if v >= min && v <= max {
+		return v, nil
+	}
+	if g.currentSettings().volPolicy != VolumeClamp {
+		return 0, fmt.Errorf("%w: %v is not in [%v, %v]", ErrVolumeOutOfRange, v, min, max)
+	}
+	return math.Max(min, math.Min(max, v)), nil
+}
+
+// volume returns current volume level of cast device
+func (g *CastDevice) volume(ctx context.Context) (float64, error) {
+	var v *controllers.Volume
+	err := g.withReconnect(ctx, func() (err error) {
+		v, err = g.castClient().Receiver().GetVolume(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if v == nil || v.Level == nil {
+		return 0, errors.New("homecast: volume level is not reported")
+	}
+	return *v.Level, nil
+}
+
+// SetMaxVolume sets volume ceiling of cast device.
+// Every volume change made through this package is capped at the ceiling.
+// Zero disables the ceiling.
+func (g *CastDevice) SetMaxVolume(level float64) {
+	g.configure(func(s *deviceSettings) { s.maxVolume = level })
+}
+
+// setVolume changes volume level of cast device, capped at its ceiling
+func (g *CastDevice) setVolume(ctx context.Context, level float64) error {
+	if max := g.currentSettings().maxVolume; max > 0 && level > max {
+		g.logf("[INFO] Volume capped: requested=%.2f max=%.2f", level, max)
+		level = max
+	}
+	return g.withReconnect(ctx, func() error {
+		_, err := g.castClient().Receiver().SetVolume(ctx, &controllers.Volume{Level: &level})
+		return err
+	})
+}
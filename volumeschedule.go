@@ -0,0 +1,70 @@
+package homecast
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// VolumeAt is volume level applied from Hour:Minute in local time
+type VolumeAt struct {
+	Hour, Minute int
+	Level        float64
+}
+
+// VolumeSchedule is default volume of announcements by time of day.
+// Each level applies from its time until the next one, wrapping around midnight.
+type VolumeSchedule []VolumeAt
+
+// At returns volume level scheduled at t. ok is false when the schedule is empty.
+func (s VolumeSchedule) At(t time.Time) (level float64, ok bool) {
+	if len(s) == 0 {
+		return 0, false
+	}
+	sorted := append(VolumeSchedule{}, s...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].minutes() < sorted[j].minutes() })
+
+	now := t.Hour()*60 + t.Minute()
+	// Before the first entry of the day, the last one of the previous day applies
+	level = sorted[len(sorted)-1].Level
+	for _, v := range sorted {
+		if v.minutes() > now {
+			break
+		}
+		level = v.Level
+	}
+	return level, true
+}
+
+func (v VolumeAt) minutes() int {
+	return v.Hour*60 + v.Minute
+}
+
+// SetVolumeSchedule sets default volume of announcements by time of day, such as quieter at night.
+// The volume is set before each Speak unless WithVolume is given.
+func (g *CastDevice) SetVolumeSchedule(s VolumeSchedule) {
+	g.configure(func(settings *deviceSettings) { settings.volSchedule = s })
+}
+
+// WithVolume sets volume of cast device before Speak, overriding the volume schedule
+func WithVolume(level float64) PlayOption {
+	return func(o *playOptions) {
+		o.volume = &level
+	}
+}
+
+// applyVolume sets volume of an announcement given by options or scheduled at now
+func (g *CastDevice) applyVolume(ctx context.Context, o playOptions) error {
+	level, ok := g.currentSettings().volSchedule.At(g.clk().Now())
+	if o.volume != nil {
+		level, ok = *o.volume, true
+	}
+	if !ok || DryRun || o.dryRun {
+		return nil
+	}
+	level, err := g.checkVolume(level, 0, 1)
+	if err != nil {
+		return err
+	}
+	return g.setVolume(ctx, level)
+}
@@ -0,0 +1,133 @@
+package homecast
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/micro/mdns"
+)
+
+const (
+	// watchInterval is interval between discovery scans of Watch
+	watchInterval = 10 * time.Second
+	// watchMissLimit is how many scans a device can miss before it is removed
+	watchMissLimit = 3
+)
+
+// DeviceEventType is kind of DeviceEvent
+type DeviceEventType int
+
+// Device event types
+const (
+	DeviceAdded DeviceEventType = iota
+	DeviceUpdated
+	DeviceRemoved
+)
+
+// DeviceEvent is a change of cast devices on the network
+type DeviceEvent struct {
+	Type  DeviceEventType
+	Entry *mdns.ServiceEntry
+}
+
+type watchedDevice struct {
+	entry  *mdns.ServiceEntry
+	missed int
+}
+
+// Watch keeps browsing cast devices, emitting events when devices appear, change address, or go offline.
+// The channel is closed when ctx is done.
+func Watch(ctx context.Context, opts ...DiscoverOption) (<-chan DeviceEvent, error) {
+	o := newDiscoverOptions(opts)
+	if len(discoverers()) == 0 {
+		return nil, errors.New("homecast: no discovery backend registered")
+	}
+
+	ch := make(chan DeviceEvent)
+	go func() {
+		defer close(ch)
+		known := map[string]*watchedDevice{}
+		emit := func(t DeviceEventType, entry *mdns.ServiceEntry) bool {
+			select {
+			case ch <- DeviceEvent{t, entry}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			seen := map[string]bool{}
+			// Failures are logged by discover
+			entries, _ := scan(ctx, o)
+			for _, entry := range entries {
+				key := deviceKey(entry)
+				seen[key] = true
+				d, ok := known[key]
+				switch {
+				case !ok:
+					known[key] = &watchedDevice{entry: entry}
+					if !emit(DeviceAdded, entry) {
+						return
+					}
+				case changed(d.entry, entry):
+					d.entry, d.missed = entry, 0
+					if !emit(DeviceUpdated, entry) {
+						return
+					}
+				default:
+					d.missed = 0
+				}
+			}
+			for key, d := range known {
+				if seen[key] {
+					continue
+				}
+				if d.missed++; d.missed >= watchMissLimit {
+					delete(known, key)
+					if !emit(DeviceRemoved, d.entry) {
+						return
+					}
+				}
+			}
+
+			select {
+			case <-o.Clock.After(watchInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// scan runs registered discovery backends once and returns found entries and failures of backends
+func scan(ctx context.Context, o *DiscoverOptions) ([]*mdns.ServiceEntry, []error) {
+	entriesCh := make(chan *mdns.ServiceEntry, 4)
+	var entries []*mdns.ServiceEntry
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for entry := range entriesCh {
+			entries = append(entries, entry)
+		}
+	}()
+	errs := discover(ctx, o, entriesCh)
+	close(entriesCh)
+	<-done
+	return entries, errs
+}
+
+// deviceKey identifies device across address changes
+func deviceKey(entry *mdns.ServiceEntry) string {
+	if id := ParseCastTXT(entry.InfoFields).ID(); id != "" {
+		return id
+	}
+	return entry.Name
+}
+
+func changed(a, b *mdns.ServiceEntry) bool {
+	return !a.AddrV4.Equal(b.AddrV4) || !a.AddrV6.Equal(b.AddrV6) || a.Port != b.Port || !reflect.DeepEqual(a.InfoFields, b.InfoFields)
+}